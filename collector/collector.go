@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
@@ -48,6 +49,11 @@ const (
 
 	// each metric starts with prefix "/intel/docker/<docker_id>"
 	lengthOfNsPrefix = 3
+
+	// metrics of docker daemon start with prefix "/intel/docker/daemon"
+	daemonNs = "daemon"
+	// metrics of docker images start with prefix "/intel/docker/images"
+	imagesNs = "images"
 )
 
 var getters map[string]container.StatGetter = map[string]container.StatGetter{
@@ -66,6 +72,17 @@ var getters map[string]container.StatGetter = map[string]container.StatGetter{
 	"network":         &network.Network{},
 	"tcp":             &network.Tcp{StatsFile: "net/tcp"},
 	"tcp6":            &network.Tcp{StatsFile: "net/tcp6"},
+	"udp":             &network.Udp{StatsFile: "net/udp"},
+	"udp6":            &network.Udp{StatsFile: "net/udp6"},
+	"unix":            &network.Unix{},
+	"raw":             &network.Raw{StatsFile: "net/raw"},
+	"raw6":            &network.Raw{StatsFile: "net/raw6"},
+	"snmp":            &network.Snmp{},
+	"ports":           &network.Ports{},
+	"peers":           &network.Peers{},
+	"sockstat":        &network.Sockstat{},
+	"conntrack":       &network.Conntrack{},
+	"qdisc":           &network.Qdisc{Reader: &network.NetlinkQdiscReader{}},
 	"filesystem":      &fs.DiskUsageCollector{},
 }
 
@@ -86,14 +103,31 @@ var names map[string]string = map[string]string{
 	"network":         "network",
 	"tcp":             "tcp",
 	"tcp6":            "tcp6",
+	"udp":             "udp",
+	"udp6":            "udp6",
+	"unix":            "unix",
+	"raw":             "raw",
+	"raw6":            "raw6",
+	"snmp":            "snmp",
+	"ports":           "ports",
+	"peers":           "peers",
+	"sockstat":        "sockstat",
+	"conntrack":       "conntrack",
+	"qdisc":           "qdisc",
 	"filesystem":      "filesystem",
 }
 
 // New returns initialized docker plugin
 func New() plugin.Collector {
+	return newEndpoints(newCollector)
+}
+
+// newCollector returns collector of one docker endpoint, its docker client is initialized by the first collection
+func newCollector() *collector {
 	return &collector{
 		containers: map[string]*container.ContainerData{},
 		mounts:     map[string]string{},
+		peers:      defaultPeersConfig,
 	}
 }
 
@@ -101,8 +135,8 @@ func New() plugin.Collector {
 func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error) {
 	var err error
 	metrics := []plugin.Metric{}
-	// setup docker client based on config only once
-	if c.client == nil {
+	// setup docker client based on config only once, docker daemon which is not running yet is connected later with backoff
+	if c.client == nil && !c.conn.down {
 		c.conf, err = getDockerConfig(mts[0].Config)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -111,25 +145,110 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 			}).Error(err)
 			return nil, err
 		}
-		err = initClient(c, c.conf["endpoint"])
+		c.tls, err = getTLSConfig(mts[0].Config)
 		if err != nil {
+			log.WithFields(log.Fields{
+				"block":    "CollectMetrics",
+				"function": "getTLSConfig",
+			}).Error(err)
+			return nil, err
+		}
+		c.peers = getPeersConfig(mts[0].Config)
+		c.networkTags, _ = mts[0].Config.GetBool("network_tags")
+		c.list = getListOptions(mts[0].Config)
+		c.diskUsage = getInterval(mts[0].Config, "disk_usage_interval", defaultDiskUsageInterval)
+		c.remote, _ = mts[0].Config.GetBool("remote_endpoint")
+		err = initClient(c, c.conf["endpoint"], c.tls)
+		if err != nil && !c.disconnected(err) {
 			log.WithFields(log.Fields{
 				"block":    "CollectMetrics",
 				"function": "initClient",
 			}).Error(err)
 			return nil, err
 		}
+		// statistics of exited containers are sampled from procfs and cgroups, which are available only for local docker daemon
+		if exited, err := mts[0].Config.GetBool("exited_containers"); err == nil && exited && c.remote {
+			log.WithFields(log.Fields{
+				"block":    "CollectMetrics",
+				"function": "newExitedTracker",
+			}).Warnf("Exited containers are not tracked for remote docker endpoint %s", c.conf["endpoint"])
+		} else if err == nil && exited {
+			c.exited = newExitedTracker(getInterval(mts[0].Config, "exited_sampling_interval", defaultExitedSamplingInterval), c.sampleContainer)
+			// events of docker daemon which is not running yet are handled by the tracker since the daemon is connected
+			if !c.conn.down {
+				if err := c.client.AddEventHandler(c.exited.handle); err != nil {
+					// only log error, metrics of containers which are listed are still available
+					log.WithFields(log.Fields{
+						"block":    "CollectMetrics",
+						"function": "AddEventHandler",
+					}).Errorf("Unable to track exited containers: %s", err)
+					c.exited = nil
+				}
+			}
+		}
 	}
 
-	// get list of all running containers
-	c.containers, err = c.client.ListContainersAsMap()
-	if err != nil {
-		log.WithFields(log.Fields{
-			"block":    "CollectMetrics",
-			"function": "ListContainersAsMap",
-		}).Error(err)
-		return nil, err
+	// docker daemon which has been down (e.g. it has been restarted) is reconnected with backoff
+	c.reconnect()
+
+	// metrics of docker daemon and docker images are not related to any container
+	mts, daemonMts := splitMetricsByNs(mts, daemonNs)
+	mts, imagesMts := splitMetricsByNs(mts, imagesNs)
+	daemonMetrics := []plugin.Metric{}
+	if len(daemonMts) > 0 {
+		daemonMetrics, err = c.collectDaemonMetrics(daemonMts)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"block":    "CollectMetrics",
+				"function": "collectDaemonMetrics",
+			}).Error(err)
+			return nil, err
+		}
+	}
+	if len(imagesMts) > 0 && !c.conn.down {
+		imagesMetrics, err := c.collectImagesMetrics(imagesMts)
+		if err != nil && c.disconnected(err) {
+			imagesMetrics, err = []plugin.Metric{}, nil
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"block":    "CollectMetrics",
+				"function": "collectImagesMetrics",
+			}).Error(err)
+			return nil, err
+		}
+		daemonMetrics = append(daemonMetrics, imagesMetrics...)
+	}
+	if len(mts) == 0 {
+		if len(daemonMetrics) == 0 {
+			return nil, fmt.Errorf("No metrics found")
+		}
+		return daemonMetrics, nil
+	}
+
+	// get list of all running containers, only host is collected while docker daemon is down
+	var listed time.Time
+	if c.conn.down {
+		c.containers = hostContainers()
+	} else {
+		listed = time.Now()
+		c.containers, err = c.client.ListContainersAsMap(c.list)
+		if err != nil && c.disconnected(err) {
+			c.containers, err, listed = hostContainers(), nil, time.Time{}
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"block":    "CollectMetrics",
+				"function": "ListContainersAsMap",
+			}).Error(err)
+			return nil, err
+		}
+	}
+	// add final snapshots of containers which have exited since the last collection
+	if c.exited != nil {
+		c.addExitedContainers(listed)
 	}
+
 	// group requested metrics by docker id
 	ridGroup, err := c.getRidGroup(mts...)
 	if err != nil {
@@ -142,6 +261,13 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 
 	// collect requested metrics per docker id
 	err = c.collect(ridGroup, c.conf["procfs"])
+	if err != nil && c.disconnected(err) {
+		// docker daemon has gone down during collection, so only host is collected
+		c.containers = hostContainers()
+		if ridGroup, err = c.getRidGroup(mts...); err == nil {
+			err = c.collect(ridGroup, c.conf["procfs"])
+		}
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
 			"block":    "CollectMetrics",
@@ -170,6 +296,31 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 				continue
 			}
 
+			// omit statistics of containers which are not running, only their specification and events are available
+			if rid != "root" && c.containers[rid].Stopped && mt.Namespace[lengthOfNsPrefix].Value != "spec" && mt.Namespace[lengthOfNsPrefix].Value != "events" {
+				continue
+			}
+
+			// omit statistics of remote docker daemon, they are read from procfs and cgroups which are available only on its host
+			if c.remote && mt.Namespace[lengthOfNsPrefix].Value == "stats" {
+				continue
+			}
+
+			// omit inventory of docker networks for containers, it is available only for host
+			if rid != "root" && mt.Namespace[lengthOfNsPrefix].Value == "networks" {
+				continue
+			}
+
+			// omit disk usage of docker objects for containers, it is available only for host
+			if rid != "root" && mt.Namespace[lengthOfNsPrefix].Value == "disk_usage" {
+				continue
+			}
+
+			// omit numbers of docker events per image for containers, they are available only for host
+			if rid != "root" && mt.Namespace[lengthOfNsPrefix].Value == "events" && mt.Namespace[lengthOfNsPrefix+1].Value == "image" {
+				continue
+			}
+
 			// omit "pids stats" for host
 			if rid == "root" && mt.Namespace[lengthOfNsPrefix].Value == "pids_stats" {
 				log.WithFields(log.Fields{
@@ -246,6 +397,70 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 					metrics = append(metrics, metric)
 				}
 
+			case "image":
+				// get numbers of docker events per image
+				images := c.containers[rid].Events.Image
+				imageNames := []string{}
+				if metricName[0] == "*" {
+					for imageName := range images {
+						imageNames = append(imageNames, imageName)
+					}
+				} else {
+					// image name is requested in the form used in namespace
+					for imageName := range images {
+						if utils.ReplaceNotAllowedCharsInNamespacePart(imageName) == metricName[0] {
+							imageNames = append(imageNames, imageName)
+						}
+					}
+					if len(imageNames) == 0 {
+						return nil, fmt.Errorf("In metric %s the given image name is invalid (no events for this image)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+				}
+
+				for _, imageName := range imageNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(imageName)
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(images[imageName], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "volume":
+				// get disk usage of docker volumes
+				volumes := c.containers[rid].DiskUsage.Volume
+				volumeNames := []string{}
+				if metricName[0] == "*" {
+					for volumeName := range volumes {
+						volumeNames = append(volumeNames, volumeName)
+					}
+				} else {
+					volumeName := metricName[0]
+					if _, ok := volumes[volumeName]; !ok {
+						return nil, fmt.Errorf("In metric %s the given volume name is invalid (no such docker volume)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					volumeNames = append(volumeNames, volumeName)
+				}
+
+				for _, volumeName := range volumeNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(volumeName)
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(volumes[volumeName], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
 			case "labels":
 				// get docker labels
 				labelKeys := []string{}
@@ -280,6 +495,52 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 				}
 
 			case "network":
+				if len(metricName) > 2 && metricName[1] == "qdisc" {
+					// get statistics of qdiscs and classes attached to network interface
+					qdiscs := c.containers[rid].Stats.Qdisc
+					netInterfaces := []string{}
+					if metricName[0] == "*" {
+						for netInterface := range qdiscs {
+							netInterfaces = append(netInterfaces, netInterface)
+						}
+					} else {
+						if _, ok := qdiscs[metricName[0]]; !ok {
+							return nil, fmt.Errorf("In metric %s the given network interface is invalid (no qdisc stats for this net interface)", strings.Join(mt.Namespace.Strings(), "/"))
+						}
+						netInterfaces = append(netInterfaces, metricName[0])
+					}
+
+					for _, ifaceName := range netInterfaces {
+						handles := []string{}
+						if metricName[2] == "*" {
+							for handle := range qdiscs[ifaceName] {
+								handles = append(handles, handle)
+							}
+						} else {
+							if _, ok := qdiscs[ifaceName][metricName[2]]; !ok {
+								return nil, fmt.Errorf("In metric %s the given handle is invalid (no stats for this qdisc)", strings.Join(mt.Namespace.Strings(), "/"))
+							}
+							handles = append(handles, metricName[2])
+						}
+
+						for _, handle := range handles {
+							rns := make([]plugin.NamespaceElement, len(ns))
+							copy(rns, ns)
+							rns[indexOfDynamicElement+lengthOfNsPrefix].Value = ifaceName
+							rns[indexOfDynamicElement+lengthOfNsPrefix+2].Value = handle
+							metric := plugin.Metric{
+								Timestamp: time.Now(),
+								Namespace: rns,
+								Data:      utils.GetValueByNamespace(qdiscs[ifaceName][handle], metricName[3:]),
+								Config:    mt.Config,
+								Version:   PLUGIN_VERSION,
+							}
+							metrics = append(metrics, metric)
+						}
+					}
+					break
+				}
+
 				//get docker network tx/rx statistics
 				netInterfaces := []string{}
 				ifaceMap := map[string]container.NetworkInterface{}
@@ -314,6 +575,242 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 					metrics = append(metrics, metric)
 				}
 
+			case "icmp", "icmp6", "icmp_msg", "ip", "ip6", "ip_ext", "tcp", "tcp_ext", "udp", "udp6", "udp_lite", "udp_lite6":
+				// get protocol counters from net/snmp, net/netstat and net/snmp6
+				counters := c.containers[rid].Stats.Connection.Snmp[statsType]
+				counterNames := []string{}
+				if metricName[0] == "*" {
+					// when counter name is requested as an asterisk - take all available counters
+					for counterName := range counters {
+						counterNames = append(counterNames, counterName)
+					}
+				} else {
+					counterName := metricName[0]
+					if _, ok := counters[counterName]; !ok {
+						return nil, fmt.Errorf("In metric %s the given counter is invalid (no stats for this counter)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					counterNames = append(counterNames, counterName)
+				}
+
+				for _, counterName := range counterNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = counterName
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      counters[counterName],
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "per_port":
+				// get inventory of listening and published ports
+				perPort := c.containers[rid].Stats.Connection.Ports.PerPort
+				ports := []string{}
+				if metricName[0] == "*" {
+					// when port is requested as an asterisk - take all listening and published ports
+					for port := range perPort {
+						ports = append(ports, port)
+					}
+				} else {
+					port := metricName[0]
+					if _, ok := perPort[port]; !ok {
+						return nil, fmt.Errorf("In metric %s the given port is invalid (port is neither listened on nor published)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					ports = append(ports, port)
+				}
+
+				for _, port := range ports {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = port
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(perPort[port], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "networks":
+				if mt.Namespace[lengthOfNsPrefix].Value == "networks" {
+					// get inventory of docker networks
+					inventory := c.containers[rid].Networks
+					networkNames := []string{}
+					if metricName[0] == "*" {
+						// when network name is requested as an asterisk - take all docker networks
+						for networkName := range inventory {
+							networkNames = append(networkNames, networkName)
+						}
+					} else {
+						// network name is requested in the form used in namespace
+						for networkName := range inventory {
+							if utils.ReplaceNotAllowedCharsInNamespacePart(networkName) == metricName[0] {
+								networkNames = append(networkNames, networkName)
+							}
+						}
+						if len(networkNames) == 0 {
+							return nil, fmt.Errorf("In metric %s the given network name is invalid (no such docker network)", strings.Join(mt.Namespace.Strings(), "/"))
+						}
+					}
+
+					for _, networkName := range networkNames {
+						rns := make([]plugin.NamespaceElement, len(ns))
+						copy(rns, ns)
+						rns[indexOfDynamicElement+lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(networkName)
+						metric := plugin.Metric{
+							Timestamp: time.Now(),
+							Namespace: rns,
+							Data:      utils.GetValueByNamespace(inventory[networkName], metricName[1:]),
+							Config:    mt.Config,
+							Version:   PLUGIN_VERSION,
+						}
+						metrics = append(metrics, metric)
+					}
+					break
+				}
+
+				// get identity of container in docker networks
+				networks := c.containers[rid].Specification.Networks
+				networkNames := []string{}
+				if metricName[0] == "*" {
+					// when network name is requested as an asterisk - take all attached networks
+					for networkName := range networks {
+						networkNames = append(networkNames, networkName)
+					}
+				} else {
+					// network name is requested in the form used in namespace
+					for networkName := range networks {
+						if utils.ReplaceNotAllowedCharsInNamespacePart(networkName) == metricName[0] {
+							networkNames = append(networkNames, networkName)
+						}
+					}
+					if len(networkNames) == 0 {
+						return nil, fmt.Errorf("In metric %s the given network name is invalid (container is not attached to this network)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+				}
+
+				for _, networkName := range networkNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(networkName)
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(networks[networkName], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "ulimits":
+				// get ulimits configured for the container
+				ulimits := c.containers[rid].Specification.Limits.Ulimits
+				ulimitNames := []string{}
+				if metricName[0] == "*" {
+					// when ulimit name is requested as an asterisk - take all configured ulimits
+					for ulimitName := range ulimits {
+						ulimitNames = append(ulimitNames, ulimitName)
+					}
+				} else {
+					ulimitName := metricName[0]
+					if _, ok := ulimits[ulimitName]; !ok {
+						return nil, fmt.Errorf("In metric %s the given ulimit is invalid (ulimit is not configured for the container)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					ulimitNames = append(ulimitNames, ulimitName)
+				}
+
+				for _, ulimitName := range ulimitNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(ulimitName)
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(ulimits[ulimitName], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "peers":
+				// get established connections aggregated by remote endpoint
+				peers := c.containers[rid].Stats.Connection.Peers
+				peerNames := []string{}
+				if metricName[0] == "*" {
+					// when peer is requested as an asterisk - take all available peers
+					for peerName := range peers {
+						peerNames = append(peerNames, peerName)
+					}
+				} else {
+					peerName := metricName[0]
+					if _, ok := peers[peerName]; !ok {
+						return nil, fmt.Errorf("In metric %s the given peer is invalid (no connections to this peer)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					peerNames = append(peerNames, peerName)
+				}
+
+				for _, peerName := range peerNames {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = peerName
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      utils.GetValueByNamespace(peers[peerName], metricName[1:]),
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
+			case "listen_backlog":
+				// get accept queue depth of listening TCP/TCP6 sockets, the preceding element says which of them
+				var tcpStats container.TcpStat
+				switch mt.Namespace[indexOfDynamicElement+lengthOfNsPrefix-2].Value {
+				case "tcp":
+					tcpStats = c.containers[rid].Stats.Connection.Tcp
+				case "tcp6":
+					tcpStats = c.containers[rid].Stats.Connection.Tcp6
+				default:
+					return nil, fmt.Errorf("In metric %s listen backlog is available only for tcp and tcp6", strings.Join(mt.Namespace.Strings(), "/"))
+				}
+
+				ports := []string{}
+				if metricName[0] == "*" {
+					// when port is requested as an asterisk - take all listening ports
+					for port := range tcpStats.ListenBacklog {
+						ports = append(ports, port)
+					}
+				} else {
+					port := metricName[0]
+					if _, ok := tcpStats.ListenBacklog[port]; !ok {
+						return nil, fmt.Errorf("In metric %s the given port is invalid (no listening socket on this port)", strings.Join(mt.Namespace.Strings(), "/"))
+					}
+					ports = append(ports, port)
+				}
+
+				for _, port := range ports {
+					rns := make([]plugin.NamespaceElement, len(ns))
+					copy(rns, ns)
+					rns[indexOfDynamicElement+lengthOfNsPrefix].Value = port
+					metric := plugin.Metric{
+						Timestamp: time.Now(),
+						Namespace: rns,
+						Data:      tcpStats.ListenBacklog[port],
+						Config:    mt.Config,
+						Version:   PLUGIN_VERSION,
+					}
+					metrics = append(metrics, metric)
+				}
+
 			case "per_cpu":
 				numOfCPUs := len(c.containers[rid].Stats.Cgroups.CpuStats.CpuUsage.PerCpu) - 1
 				if metricName[0] == "*" {
@@ -681,10 +1178,43 @@ func (c *collector) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error)
 					metrics[i].Tags[lkey] = lval
 				}
 			}
+
+			// final metrics of containers which have exited since the last collection are tagged
+			if c.containers[rid].Exited {
+				tags := map[string]string{"exited": "true"}
+				for key, val := range metrics[i].Tags {
+					tags[key] = val
+				}
+				metrics[i].Tags = tags
+			}
+
+			// adding image of the container, the image name can refer to other image than the one which the container was created from
+			if spec := c.containers[rid].Specification; spec.ImageID != "" {
+				tags := map[string]string{"image_id": spec.ImageID}
+				if spec.ImageDigest != "" {
+					tags["image_digest"] = spec.ImageDigest
+				}
+				for key, val := range metrics[i].Tags {
+					tags[key] = val
+				}
+				metrics[i].Tags = tags
+			}
+
+			// adding docker networks of the container to network metrics, when it is enabled in config
+			if c.networkTags && metrics[i].Namespace[lengthOfNsPrefix].Value == "stats" && metrics[i].Namespace[lengthOfNsPrefix+1].Value == "network" {
+				tags := map[string]string{}
+				for key, val := range metrics[i].Tags {
+					tags[key] = val
+				}
+				for key, val := range getNetworksTags(c.containers[rid].Specification.Networks) {
+					tags[key] = val
+				}
+				metrics[i].Tags = tags
+			}
 		}
 	}
 
-	return metrics, nil
+	return append(daemonMetrics, metrics...), nil
 }
 
 // GetMetricTypes returns list of available metrics
@@ -699,6 +1229,8 @@ func (c *collector) GetMetricTypes(cfg plugin.Config) ([]plugin.Metric, error) {
 
 	dockerMetrics := []string{}
 	utils.FromCompositeObject(data, "", &dockerMetrics)
+	// statistics of qdiscs are exposed under network interface, next to its statistics from net/dev
+	utils.FromCompositionTags(container.QdiscStat{}, "stats/network/*/qdisc/*", &dockerMetrics)
 	nscreator := nsCreator{dynamicElements: definedDynamicElements}
 	for _, metricName := range dockerMetrics {
 		ns := plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
@@ -718,6 +1250,31 @@ func (c *collector) GetMetricTypes(cfg plugin.Config) ([]plugin.Metric, error) {
 		metricTypes = append(metricTypes, metricType)
 	}
 
+	// metrics of docker daemon are exposed under their own namespace, e.g. /intel/docker/daemon/images
+	daemonMetrics := []string{}
+	utils.FromCompositeObject(container.DaemonInfo{}, "", &daemonMetrics)
+	utils.FromCompositeObject(container.DaemonConnection{}, "", &daemonMetrics)
+	for _, metricName := range daemonMetrics {
+		metricType := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME, daemonNs).AddStaticElements(strings.Split(metricName, "/")...),
+			Version:   PLUGIN_VERSION,
+		}
+		metricTypes = append(metricTypes, metricType)
+	}
+
+	// metrics of docker images are exposed under their own namespace, e.g. /intel/docker/images/nginx:1_13/size
+	imagesMetrics := []string{}
+	utils.FromCompositeObject(container.ImageInventory{}, "", &imagesMetrics)
+	for _, metricName := range imagesMetrics {
+		metricType := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME, imagesNs).
+				AddDynamicElement("image_name", "a repo tag of docker image or its short id when it has no tag").
+				AddStaticElements(strings.Split(metricName, "/")...),
+			Version: PLUGIN_VERSION,
+		}
+		metricTypes = append(metricTypes, metricType)
+	}
+
 	return metricTypes, nil
 }
 
@@ -731,22 +1288,132 @@ func (c *collector) GetConfigPolicy() (plugin.ConfigPolicy, error) {
 		false,
 		plugin.SetDefaultString("unix:///var/run/docker.sock"))
 
+	policy.AddNewStringRule(configKey,
+		"tls_ca",
+		false,
+		plugin.SetDefaultString(""))
+
+	policy.AddNewStringRule(configKey,
+		"tls_cert",
+		false,
+		plugin.SetDefaultString(""))
+
+	policy.AddNewStringRule(configKey,
+		"tls_key",
+		false,
+		plugin.SetDefaultString(""))
+
+	policy.AddNewBoolRule(configKey,
+		"tls_verify",
+		false,
+		plugin.SetDefaultBool(true))
+
+	policy.AddNewBoolRule(configKey,
+		"remote_endpoint",
+		false,
+		plugin.SetDefaultBool(false))
+
 	policy.AddNewStringRule(configKey,
 		"procfs",
 		false,
 		plugin.SetDefaultString("/proc"))
 
+	policy.AddNewBoolRule(configKey,
+		"network_tags",
+		false,
+		plugin.SetDefaultBool(false))
+
+	policy.AddNewIntRule(configKey,
+		"peers_top_n",
+		false,
+		plugin.SetDefaultInt(int64(defaultPeersConfig.topN)),
+		plugin.SetMinInt(0))
+
+	policy.AddNewIntRule(configKey,
+		"peers_ipv4_prefix",
+		false,
+		plugin.SetDefaultInt(int64(defaultPeersConfig.ipv4Prefix)),
+		plugin.SetMinInt(0),
+		plugin.SetMaxInt(32))
+
+	policy.AddNewIntRule(configKey,
+		"peers_ipv6_prefix",
+		false,
+		plugin.SetDefaultInt(int64(defaultPeersConfig.ipv6Prefix)),
+		plugin.SetMinInt(0),
+		plugin.SetMaxInt(128))
+
+	policy.AddNewBoolRule(configKey,
+		"peers_by_port",
+		false,
+		plugin.SetDefaultBool(defaultPeersConfig.byPort))
+
+	policy.AddNewBoolRule(configKey,
+		"all_containers",
+		false,
+		plugin.SetDefaultBool(false))
+
+	policy.AddNewStringRule(configKey,
+		"container_statuses",
+		false,
+		plugin.SetDefaultString(""))
+
+	policy.AddNewBoolRule(configKey,
+		"container_size",
+		false,
+		plugin.SetDefaultBool(false))
+
+	policy.AddNewStringRule(configKey,
+		"container_size_interval",
+		false,
+		plugin.SetDefaultString(defaultSizeInterval.String()))
+
+	policy.AddNewBoolRule(configKey,
+		"container_events",
+		false,
+		plugin.SetDefaultBool(false))
+
+	policy.AddNewStringRule(configKey,
+		"container_resync_interval",
+		false,
+		plugin.SetDefaultString(defaultResyncInterval.String()))
+
+	policy.AddNewBoolRule(configKey,
+		"exited_containers",
+		false,
+		plugin.SetDefaultBool(false))
+
+	policy.AddNewStringRule(configKey,
+		"exited_sampling_interval",
+		false,
+		plugin.SetDefaultString(defaultExitedSamplingInterval.String()))
+
+	policy.AddNewStringRule(configKey,
+		"disk_usage_interval",
+		false,
+		plugin.SetDefaultString(defaultDiskUsageInterval.String()))
+
 	return *policy, nil
 }
 
 type collector struct {
-	containers map[string]*container.ContainerData // holds data for a container under its short id
-	client     container.DockerClientInterface     // client for communication with docker (basic info, stats, mount points)
-	cgroupfs   string                              // CgroupDriver from docker engine
-	driver     string                              // Driver from docker engine
-	rootDir    string                              // Storage mount point for docker containers
-	mounts     map[string]string                   // cache for cgroup mountpoints
-	conf       map[string]string                   // plugin configuration passed with metrics
+	containers    map[string]*container.ContainerData // holds data for a container under its short id
+	client        container.DockerClientInterface     // client for communication with docker (basic info, stats, mount points)
+	cgroupfs      string                              // CgroupDriver from docker engine
+	driver        string                              // Driver from docker engine
+	rootDir       string                              // Storage mount point for docker containers
+	dockerVersion []int                               // major and minor version of docker engine
+	mounts        map[string]string                   // cache for cgroup mountpoints
+	conf          map[string]string                   // plugin configuration passed with metrics
+	peers         peersConfig                         // configuration of aggregating connections by remote endpoint
+	networkTags   bool                                // whether network metrics are tagged with docker networks of the container
+	list          container.ListOptions               // which containers are listed (by default only running ones)
+	exited        *exitedTracker                      // final snapshots of short-lived containers, nil when it is disabled
+	diskUsage     time.Duration                       // how often disk usage of docker objects is computed
+	tls           container.TLSOptions                // certificates used to connect docker endpoint secured by TLS
+	conn          connection                          // state of connection to docker daemon, it is reconnected when it is down
+	clientMutex   sync.RWMutex                        // guards client and rootDir which are replaced on reconnection
+	remote        bool                                // whether docker daemon runs on another host, so procfs and cgroups of its containers are not available
 }
 
 // getRidGroup returns quested metrics grouped by docker ids
@@ -796,10 +1463,35 @@ func (c *collector) getRidGroup(mt ...plugin.Metric) (map[string]map[string]stru
 func (c *collector) collect(ridGroup map[string]map[string]struct{}, procfs string) error {
 	var err error
 	var cont *docker.Container
+	// sources shared by all containers (e.g. qdiscs of host) are read only once per collection
+	collection := time.Now()
+	// docker events are copied once per collection and shared by all containers
+	var events map[string]container.EventsStats
 	for rid, groups := range ridGroup {
 		opts := make(container.GetStatOpt)
+		opts["collection"] = collection
 		opts["procfs"] = procfs
 		opts["root_dir"] = c.rootDir
+		opts["docker_version"] = c.dockerVersion
+		opts["peers_top_n"] = c.peers.topN
+		opts["peers_ipv4_prefix"] = c.peers.ipv4Prefix
+		opts["peers_ipv6_prefix"] = c.peers.ipv6Prefix
+		opts["peers_by_port"] = c.peers.byPort
+
+		// docker events are recorded also for containers which are not running
+		if _, ok := groups["events"]; ok {
+			if events == nil {
+				events = c.getEventsStats()
+			}
+			if events != nil {
+				c.containers[rid].Events = events[rid]
+			}
+		}
+
+		// final snapshot of exited container is already taken
+		if c.containers[rid].Exited {
+			continue
+		}
 
 		if rid == "root" {
 			opts["is_host"] = true
@@ -811,10 +1503,34 @@ func (c *collector) collect(ridGroup map[string]map[string]struct{}, procfs stri
 			if err != nil {
 				return err
 			}
+			// cached pid of the container is revalidated, it is replaced when the container has been restarted
+			// and docker events which drop the cached inspect info have been missed
+			if !c.remote && cont.State.Pid > 0 && !isContainerProcess(procfs, cont.State.Pid, cont.State.StartedAt) {
+				cont, err = c.client.ReinspectContainer(rid)
+				if err != nil {
+					return err
+				}
+			}
 			opts["is_host"] = false
 			opts["pid"] = cont.State.Pid
 			opts["container_id"] = cont.ID
 			opts["container_drv"] = cont.Driver
+			opts["published_ports"] = getPublishedPorts(cont)
+
+			shortID, err := container.GetShortID(rid)
+			if err != nil {
+				return err
+			}
+			c.containers[shortID].Specification.Networks = getNetworksSpec(cont)
+			c.containers[shortID].Specification.Status = getStatusSpec(cont, time.Now())
+			c.containers[shortID].Specification.State = getStateSpec(cont, time.Now())
+			c.containers[shortID].Specification.Health = getHealthSpec(cont)
+			setConfigSpec(&c.containers[shortID].Specification, cont, getHostConfigExtras(c.client, rid))
+
+			// cgroups and procfs of the container are available only when it is running
+			if c.containers[shortID].Stopped {
+				continue
+			}
 		}
 
 		for group := range groups {
@@ -823,16 +1539,64 @@ func (c *collector) collect(ridGroup map[string]map[string]struct{}, procfs stri
 				continue
 			}
 
+			// numbers of docker events are already set
+			if group == "events" {
+				continue
+			}
+
+			// inventory of docker networks is available only for host
+			if group == "networks" {
+				if rid == "root" && !c.conn.down {
+					networks, err := c.client.ListNetworks()
+					if err != nil {
+						// only log error when it was not possible to access docker networks
+						log.WithFields(log.Fields{
+							"block": "collect",
+						}).Error(err)
+						continue
+					}
+					c.containers[rid].Networks = getNetworksInventory(networks)
+				}
+				continue
+			}
+
+			// disk usage of docker objects is available only for host
+			if group == "disk_usage" {
+				// docker client is not available until docker daemon which is not running at start is connected
+				if rid == "root" && c.client != nil {
+					usage, err := c.client.GetDiskUsage(c.diskUsage)
+					if err != nil {
+						// only log error, disk usage is computed in background and it is not available until the first result
+						log.WithFields(log.Fields{
+							"block": "collect",
+						}).Error(err)
+						continue
+					}
+					c.containers[rid].DiskUsage = *usage
+				}
+				continue
+			}
+
+			// procfs and cgroups of this host do not belong to remote docker daemon, so its statistics are not available
+			if c.remote {
+				continue
+			}
+
 			if group == "pids_stats" && rid == "root" {
 				continue
 			}
 
-			if group != "network" && group != "tcp" && group != "tcp6" && group != "filesystem" {
+			if isCgroupGroup(group) {
 				cgroup := names[group]
 				// try to find cgroup mount point in cache
 				cpath, exists := c.mounts[cgroup]
 				if !exists {
-					cpath, err = c.client.FindCgroupMountpoint(procfs, cgroup)
+					// mount point on host is found also before docker daemon which is not running at start is connected
+					if c.client != nil {
+						cpath, err = c.client.FindCgroupMountpoint(procfs, cgroup)
+					} else {
+						cpath, err = container.FindCgroupMountpoint(procfs, cgroup)
+					}
 					if err != nil {
 						return err
 					}
@@ -865,3 +1629,120 @@ func (c *collector) collect(ridGroup map[string]map[string]struct{}, procfs stri
 
 	return nil
 }
+
+// addExitedContainers adds final snapshots of exited containers which are not listed (or listed as not running),
+// containers started before the listing are not sampled anymore (zero time of listing says that containers have not been listed)
+func (c *collector) addExitedContainers(listed time.Time) {
+	for id, data := range c.exited.take() {
+		if cont, ok := c.containers[id]; !ok || cont.Stopped {
+			c.containers[id] = data
+		}
+	}
+
+	c.exited.forget(listed, func(id string) bool {
+		cont, ok := c.containers[id]
+		return ok && cont.Stopped
+	})
+}
+
+// collectDaemonMetrics returns values of requested metrics of docker daemon
+func (c *collector) collectDaemonMetrics(mts []plugin.Metric) ([]plugin.Metric, error) {
+	// information about docker daemon is not available while it is down, only state of connection is
+	var info *container.DaemonInfo
+	if !c.conn.down {
+		var err error
+		info, err = c.client.GetDaemonInfo()
+		if err != nil && !c.disconnected(err) {
+			return nil, err
+		}
+	}
+	conn := c.connectionStats()
+
+	metrics := []plugin.Metric{}
+	for _, mt := range mts {
+		metricName := mt.Namespace.Strings()[lengthOfNsPrefix:]
+		data := utils.GetValueByNamespace(conn, metricName)
+		if data == nil {
+			if info == nil {
+				continue
+			}
+			data = utils.GetValueByNamespace(info, metricName)
+		}
+		metric := plugin.Metric{
+			Timestamp: time.Now(),
+			Namespace: mt.Namespace,
+			Data:      data,
+			Config:    mt.Config,
+			Version:   PLUGIN_VERSION,
+		}
+		if metric.Data == nil {
+			return nil, fmt.Errorf("In metric %s the given name is invalid (no such metric of docker daemon)", strings.Join(mt.Namespace.Strings(), "/"))
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, nil
+}
+
+// collectImagesMetrics returns values of requested metrics of docker images, image name is given in the form used in namespace
+func (c *collector) collectImagesMetrics(mts []plugin.Metric) ([]plugin.Metric, error) {
+	inventory, err := c.client.GetImagesInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []plugin.Metric{}
+	for _, mt := range mts {
+		if len(mt.Namespace) <= lengthOfNsPrefix+1 {
+			return nil, fmt.Errorf("Invalid name of metric %s", strings.Join(mt.Namespace.Strings(), "/"))
+		}
+		requested := mt.Namespace[lengthOfNsPrefix].Value
+		metricName := mt.Namespace.Strings()[lengthOfNsPrefix+1:]
+
+		imageNames := []string{}
+		for imageName := range inventory {
+			if requested == "*" || utils.ReplaceNotAllowedCharsInNamespacePart(imageName) == requested {
+				imageNames = append(imageNames, imageName)
+			}
+		}
+		if requested != "*" && len(imageNames) == 0 {
+			return nil, fmt.Errorf("In metric %s the given image name is invalid (no such docker image)", strings.Join(mt.Namespace.Strings(), "/"))
+		}
+
+		for _, imageName := range imageNames {
+			ns := make([]plugin.NamespaceElement, len(mt.Namespace))
+			copy(ns, mt.Namespace)
+			ns[lengthOfNsPrefix].Value = utils.ReplaceNotAllowedCharsInNamespacePart(imageName)
+			metric := plugin.Metric{
+				Timestamp: time.Now(),
+				Namespace: ns,
+				Data:      utils.GetValueByNamespace(inventory[imageName], metricName),
+				Config:    mt.Config,
+				Version:   PLUGIN_VERSION,
+			}
+			if metric.Data == nil {
+				return nil, fmt.Errorf("In metric %s the given name is invalid (no such metric of docker image)", strings.Join(mt.Namespace.Strings(), "/"))
+			}
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics, nil
+}
+
+// getEventsStats returns numbers of docker events per container (totals of all containers for host),
+// only log error and return nil when it was not possible to monitor docker events
+func (c *collector) getEventsStats() map[string]container.EventsStats {
+	// docker events are not monitored until docker daemon which is not running at start is connected
+	if c.client == nil {
+		return nil
+	}
+	stats, err := c.client.GetEventsStats()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"block": "collect",
+		}).Error(err)
+		return nil
+	}
+	return stats
+}
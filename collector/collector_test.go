@@ -24,6 +24,10 @@ package collector
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -122,6 +126,30 @@ var mockMts = []plugin.Metric{
 			AddStaticElements("stats", "connection", "tcp6", "established"),
 		Config: metricConf,
 	},
+	plugin.Metric{
+		Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+			AddDynamicElement("docker_id", "an id of docker container").
+			AddStaticElements("stats", "connection", "udp", "sockets"),
+		Config: metricConf,
+	},
+	plugin.Metric{
+		Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+			AddDynamicElement("docker_id", "an id of docker container").
+			AddStaticElements("stats", "connection", "unix", "stream"),
+		Config: metricConf,
+	},
+	plugin.Metric{
+		Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+			AddDynamicElement("docker_id", "an id of docker container").
+			AddStaticElements("stats", "connection", "sockstat", "tcp", "orphan"),
+		Config: metricConf,
+	},
+	plugin.Metric{
+		Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+			AddDynamicElement("docker_id", "an id of docker container").
+			AddStaticElements("stats", "connection", "conntrack", "count"),
+		Config: metricConf,
+	},
 
 	// representation of metrics grouped as `network`
 	plugin.Metric{
@@ -165,6 +193,15 @@ func TestGetMetricTypes(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(metrics, ShouldNotBeEmpty)
 
+			Convey("metrics of docker daemon are available", func() {
+				namespaces := []string{}
+				for _, metric := range metrics {
+					namespaces = append(namespaces, strings.Join(metric.Namespace.Strings(), "/"))
+				}
+				So(namespaces, ShouldContain, "intel/docker/daemon/containers_running")
+				So(namespaces, ShouldContain, "intel/docker/daemon/version")
+			})
+
 			Convey("check if version is set ", func() {
 				for _, metric := range metrics {
 					So(metric.Version, ShouldEqual, PLUGIN_VERSION)
@@ -221,6 +258,7 @@ func TestCollectMetrics(t *testing.T) {
 		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
 		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, fmt.Errorf("Cgroup {%s} mountpoint not found", mock.Anything))
 		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
 		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
 		dockerPlg.client = mc
 		metrics, err := dockerPlg.CollectMetrics(mockMts)
@@ -234,6 +272,7 @@ func TestCollectMetrics(t *testing.T) {
 		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
 		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
 		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
 		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
 		getters = MockGetters
 		dockerPlg.client = mc
@@ -251,10 +290,884 @@ func TestCollectMetrics(t *testing.T) {
 		// collected metrics should not contain an asterisk in a namespace
 		Convey("collected metrics have specified namespace", func() {
 			for _, metric := range metrics {
-				So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				So(metric.Namespace.Strings(), ShouldNotContain, "*")
+			}
+		})
+
+	})
+
+	Convey("successful revalidation of cached inspect info when process of the container has changed", t, func() {
+		procfs, err := ioutil.TempDir("", "procfs")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(procfs)
+		dockerPlg.conf["procfs"] = procfs
+		defer delete(dockerPlg.conf, "procfs")
+
+		// processes are started relatively to boot time of the host in clock ticks
+		btime := int64(1500000000)
+		So(ioutil.WriteFile(filepath.Join(procfs, "stat"), []byte(fmt.Sprintf("cpu  1 2 3 4\nbtime %d\nprocesses 100\n", btime)), 0644), ShouldBeNil)
+		writeProcess := func(pid int, started time.Time) {
+			ticks := started.Sub(time.Unix(btime, 0)) / (time.Second / clockTicks)
+			stat := fmt.Sprintf("%d (nginx: master) S 1 %d %d 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 %d 100 10", pid, pid, pid, ticks)
+			So(os.MkdirAll(filepath.Join(procfs, strconv.Itoa(pid)), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(procfs, strconv.Itoa(pid), "stat"), []byte(stat), 0644), ShouldBeNil)
+		}
+		started := time.Unix(btime+3600, 0)
+		restarted := time.Unix(btime+7200, 0)
+
+		fullID := mockListOfContainers[mockDockerID].ID
+		mockContainer := func(pid int, startedAt time.Time, ip string) *docker.Container {
+			return &docker.Container{
+				ID:    fullID,
+				State: docker.State{Running: true, Pid: pid, StartedAt: startedAt},
+				NetworkSettings: &docker.NetworkSettings{
+					Networks: map[string]docker.ContainerNetwork{"bridge": {IPAddress: ip}},
+				},
+			}
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("InspectContainer", mock.Anything).Return(mockContainer(4242, started, "172.17.0.2"), nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("ReinspectContainer", mockDockerID).Return(mockContainer(4343, restarted, "172.17.0.3"), nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		mockMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("spec", "networks").
+				AddDynamicElement("network_name", "a name of docker network").
+				AddStaticElement("ip_address"),
+			Config: metricConf,
+		}
+		mockMt.Namespace[2].Value = mockDockerID
+		mockMt.Namespace[5].Value = "bridge"
+		cpuMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+			Config: metricConf,
+		}
+		cpuMt.Namespace[2].Value = mockDockerID
+
+		Convey("cached inspect info is used when the process has been started together with the container", func() {
+			// start time of the process is known only with precision of seconds
+			writeProcess(4242, started.Add(time.Second))
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.2")
+			mc.AssertNotCalled(t, "ReinspectContainer", mockDockerID)
+		})
+
+		Convey("stale pid of restarted container is replaced when the process does not exist anymore", func() {
+			writeProcess(4343, restarted)
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt, cpuMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.3")
+			mc.AssertCalled(t, "ReinspectContainer", mockDockerID)
+			mc.AssertCalled(t, "FindControllerMountpoint", mock.Anything, "4343", procfs)
+		})
+
+		Convey("stale pid of restarted container is replaced when the pid has been reused by other process", func() {
+			writeProcess(4242, started.Add(time.Hour))
+			writeProcess(4343, restarted)
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.3")
+			mc.AssertCalled(t, "ReinspectContainer", mockDockerID)
+		})
+
+		Convey("process started by docker long before start time of the container is not inspected again", func() {
+			// prestart hooks of the container can take long time on loaded host
+			writeProcess(4242, started.Add(-30*time.Second))
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.2")
+			mc.AssertNotCalled(t, "ReinspectContainer", mockDockerID)
+		})
+
+		Convey("statistics are read from the process given by docker when the container is inspected again", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt, cpuMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.3")
+			mc.AssertCalled(t, "FindControllerMountpoint", mock.Anything, "4343", procfs)
+		})
+
+		Convey("start time of the process is not overflowed for host which has been running for years", func() {
+			uptime := 4 * 365 * 24 * time.Hour
+			writeProcess(4242, time.Unix(btime, 0).Add(uptime))
+
+			processStarted, err := getProcessStartTime(procfs, 4242)
+			So(err, ShouldBeNil)
+			So(processStarted, ShouldResemble, time.Unix(btime, 0).Add(uptime))
+		})
+	})
+
+	Convey("successful collect metrics describing docker networks of the container", t, func() {
+		mockContainer := &docker.Container{
+			NetworkSettings: &docker.NetworkSettings{
+				Networks: map[string]docker.ContainerNetwork{
+					"bridge": {IPAddress: "172.17.0.2", MacAddress: "02:42:ac:11:00:02", Gateway: "172.17.0.1"},
+					"my.net": {IPAddress: "10.0.0.2", MacAddress: "02:42:0a:00:00:02", Gateway: "10.0.0.1"},
+				},
+			},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(mockContainer, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		mockMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("spec", "networks").
+				AddDynamicElement("network_name", "a name of docker network").
+				AddStaticElement("ip_address"),
+			Config: metricConf,
+		}
+		mockMt.Namespace[2].Value = mockDockerID
+
+		Convey("successful when specified network exists", func() {
+			mockMt.Namespace[5].Value = "bridge"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, "172.17.0.2")
+		})
+		Convey("successful when specified network name contains not allowed characters", func() {
+			mockMt.Namespace[5].Value = "my_net"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, "10.0.0.2")
+		})
+		Convey("successful when network name is requested as an asterisk", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			names := []string{}
+			for _, metric := range metrics {
+				names = append(names, metric.Namespace[5].Value)
+			}
+			// not allowed characters in network name are replaced
+			So(names, ShouldContain, "my_net")
+			So(names, ShouldContain, "bridge")
+		})
+		Convey("return an error when specified network is invalid", func() {
+			mockMt.Namespace[5].Value = "host"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given network name is invalid (container is not attached to this network)", strings.Join(mockMt.Namespace.Strings(), "/")))
+		})
+		Convey("successful tagging network metrics with docker networks when it is enabled", func() {
+			dockerPlg.networkTags = true
+			defer func() { dockerPlg.networkTags = false }()
+
+			netMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "network").
+					AddDynamicElement("network_interface", "a name of network interface or 'total' for aggregate").
+					AddStaticElement("rx_bytes"),
+				Config: metricConf,
+			}
+			netMt.Namespace[2].Value = mockDockerID
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{netMt, mockMt})
+			So(err, ShouldBeNil)
+			for _, metric := range metrics {
+				if metric.Namespace[4].Value != "network" {
+					// other metrics are not tagged with docker networks
+					So(metric.Tags, ShouldNotContainKey, "networks")
+					continue
+				}
+				So(metric.Tags["networks"], ShouldEqual, "bridge,my.net")
+				So(metric.Tags["ip_addresses"], ShouldEqual, "172.17.0.2,10.0.0.2")
+				So(metric.Tags["mac_addresses"], ShouldEqual, "02:42:ac:11:00:02,02:42:0a:00:00:02")
+				// labels are still available as tags
+				So(metric.Tags["lkey1"], ShouldEqual, "lval1")
+			}
+			So(mockListOfContainers[mockDockerID].Specification.Labels, ShouldNotContainKey, "networks")
+		})
+	})
+
+	Convey("successful collect metrics describing lifecycle state of the container", t, func() {
+		mockContainer := &docker.Container{
+			State: docker.State{
+				Running:   true,
+				ExitCode:  137,
+				StartedAt: time.Now().Add(-time.Hour),
+			},
+			RestartCount: 3,
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("InspectContainer", mock.Anything).Return(mockContainer, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		dockerPlg.client = mc
+
+		mockMt := func(name string) plugin.Metric {
+			mt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("spec", "state", name),
+				Config: metricConf,
+			}
+			mt.Namespace[2].Value = mockDockerID
+			return mt
+		}
+
+		metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt("code"), mockMt("restart_count"), mockMt("exit_code"), mockMt("uptime")})
+		So(err, ShouldBeNil)
+		So(len(metrics), ShouldEqual, 4)
+		So(metrics[0].Data, ShouldEqual, 1)
+		So(metrics[1].Data, ShouldEqual, 3)
+		So(metrics[2].Data, ShouldEqual, 137)
+		So(metrics[3].Data, ShouldBeGreaterThanOrEqualTo, 3600)
+	})
+
+	Convey("successful collect metrics describing configuration of the container", t, func() {
+		mockContainer := &docker.Container{
+			Name: "/web",
+			HostConfig: &docker.HostConfig{
+				Memory:  536870912,
+				Ulimits: []docker.ULimit{{Name: "nofile", Soft: 1024, Hard: 4096}, {Name: "nproc", Soft: 512, Hard: 512}},
+			},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("InspectContainer", mock.Anything).Return(mockContainer, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		dockerPlg.client = mc
+
+		mockMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("spec", "limits", "ulimits").
+				AddDynamicElement("ulimit_name", "a name of ulimit, e.g. nofile").
+				AddStaticElement("hard"),
+			Config: metricConf,
+		}
+		mockMt.Namespace[2].Value = mockDockerID
+
+		Convey("successful when specified ulimit is configured", func() {
+			mockMt.Namespace[6].Value = "nofile"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, 4096)
+		})
+		Convey("successful when ulimit is requested as an asterisk", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			for _, metric := range metrics {
+				So(metric.Namespace.Strings(), ShouldNotContain, "*")
+			}
+		})
+		Convey("return an error when specified ulimit is not configured", func() {
+			mockMt.Namespace[6].Value = "core"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given ulimit is invalid (ulimit is not configured for the container)", strings.Join(mockMt.Namespace.Strings(), "/")))
+		})
+		Convey("successful when configuration metric is static", func() {
+			nameMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("spec", "name"),
+				Config: metricConf,
+			}
+			nameMt.Namespace[2].Value = mockDockerID
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{nameMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Data, ShouldEqual, "web")
+		})
+	})
+
+	Convey("successful collect metrics of containers which are not running", t, func() {
+		mockStoppedID := "b4a1e9f07c3d"
+		mockContainers := map[string]*container.ContainerData{
+			mockDockerHost: {ID: "/", Stats: container.NewStatistics()},
+			mockStoppedID: {
+				ID:            "b4a1e9f07c3d5c2f0a7e1b0d6c4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e",
+				Specification: container.Specification{Status: "Exited (137) 5 minutes ago"},
+				Stats:         container.NewStatistics(),
+				Stopped:       true,
+			},
+		}
+		mockContainer := &docker.Container{
+			State: docker.State{OOMKilled: true, ExitCode: 137, StartedAt: time.Now().Add(-time.Hour), FinishedAt: time.Now().Add(-5 * time.Minute)},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(mockContainer, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		stateMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("spec", "state", "exit_code"),
+			Config: metricConf,
+		}
+		statsMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "memory_stats", "cache"),
+			Config: metricConf,
+		}
+
+		metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{stateMt, statsMt})
+		So(err, ShouldBeNil)
+		// cgroup getters are not called with PID of the container which is not running
+		mc.AssertNotCalled(t, "FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything)
+		ids := []string{}
+		for _, metric := range metrics {
+			ids = append(ids, metric.Namespace[2].Value)
+			if metric.Namespace[2].Value == mockStoppedID {
+				So(metric.Namespace[3].Value, ShouldEqual, "spec")
+				So(metric.Data, ShouldEqual, 137)
+			}
+		}
+		So(ids, ShouldContain, mockStoppedID)
+		So(ids, ShouldContain, mockDockerHost)
+	})
+
+	Convey("successful collect metrics describing docker events", t, func() {
+		mockStoppedID := "b4a1e9f07c3d"
+		mockContainers := map[string]*container.ContainerData{
+			mockDockerHost: {ID: "/", Stats: container.NewStatistics()},
+			mockStoppedID: {
+				ID:            "b4a1e9f07c3d5c2f0a7e1b0d6c4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e",
+				Specification: container.Specification{Status: "Exited (137) 5 minutes ago"},
+				Stats:         container.NewStatistics(),
+				Stopped:       true,
+			},
+		}
+		mockEvents := map[string]container.EventsStats{
+			mockStoppedID: {
+				Count:    container.EventCounters{Start: 5, Die: 5, Restart: 4},
+				Duration: container.EventDurations{CreateToStart: 0.25, KillToDie: 10.5},
+			},
+			mockDockerHost: {
+				Count: container.EventCounters{Start: 7, Die: 6, Restart: 4},
+				Image: map[string]container.EventCounters{
+					"my/image:latest": {Start: 5, Die: 5},
+					"nginx":           {Start: 2, Die: 1},
+				},
+			},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockContainers, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("GetEventsStats").Return(mockEvents, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		countMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("events", "count", "restart"),
+			Config: metricConf,
+		}
+		durationMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("events", "duration", "kill_to_die"),
+			Config: metricConf,
+		}
+		imageMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("events", "image").
+				AddDynamicElement("image_name", "a name of docker image").
+				AddStaticElement("start"),
+			Config: metricConf,
+		}
+
+		Convey("successful for the container which is not running", func() {
+			countMt.Namespace[2].Value = mockStoppedID
+			durationMt.Namespace[2].Value = mockStoppedID
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{countMt, durationMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			data := map[string]interface{}{}
+			for _, metric := range metrics {
+				data[metric.Namespace[5].Value] = metric.Data
+			}
+			So(data["restart"], ShouldEqual, 4)
+			So(data["kill_to_die"], ShouldEqual, 10.5)
+		})
+		Convey("events are copied once per collection", func() {
+			countMt.Namespace[2].Value = "*"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{countMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldBeGreaterThan, 1)
+			mc.AssertNumberOfCalls(t, "GetEventsStats", 1)
+		})
+		Convey("successful for totals of host", func() {
+			countMt.Namespace[2].Value = mockDockerHost
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{countMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Data, ShouldEqual, 4)
+		})
+		Convey("successful when image name is requested as an asterisk", func() {
+			imageMt.Namespace[2].Value = "*"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{imageMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			data := map[string]interface{}{}
+			for _, metric := range metrics {
+				// numbers of events per image are available only for host
+				So(metric.Namespace[2].Value, ShouldEqual, mockDockerHost)
+				data[metric.Namespace[5].Value] = metric.Data
+			}
+			So(data["my_image:latest"], ShouldEqual, 5)
+			So(data["nginx"], ShouldEqual, 2)
+		})
+		Convey("return an error when specified image has no events", func() {
+			imageMt.Namespace[2].Value = mockDockerHost
+			imageMt.Namespace[5].Value = "busybox"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{imageMt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given image name is invalid (no events for this image)", strings.Join(imageMt.Namespace.Strings(), "/")))
+		})
+	})
+
+	Convey("successful collect final metrics of containers which have exited since the last collection", t, func() {
+		mockExitedShortID := "c48e074e044e"
+		snapshot := &container.ContainerData{
+			ID:            mockExitedID,
+			Specification: container.Specification{Status: "exited", Labels: map[string]string{"job": "batch"}},
+			Stats:         container.NewStatistics(),
+			Exited:        true,
+		}
+		snapshot.Stats.Cgroups.CpuStats.CpuUsage.Total = 4200
+		mockContainers := func() map[string]*container.ContainerData {
+			return map[string]*container.ContainerData{
+				mockDockerHost: {ID: "/", Stats: container.NewStatistics()},
+			}
+		}
+		mc := new(ClientMock)
+		// each listing returns new map of containers
+		mc.On("ListContainersAsMap").Return(mockContainers(), nil).Once()
+		mc.On("ListContainersAsMap").Return(mockContainers(), nil).Once()
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+		dockerPlg.exited = newExitedTracker(time.Second, nil)
+		dockerPlg.exited.snapshots[mockExitedShortID] = snapshot
+		defer func() { dockerPlg.exited = nil }()
+
+		cpuMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+			Config: metricConf,
+		}
+		cpuMt.Namespace[2].Value = "*"
+
+		metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{cpuMt})
+		So(err, ShouldBeNil)
+		found := false
+		for _, metric := range metrics {
+			if metric.Namespace[2].Value == mockExitedShortID {
+				found = true
+				So(metric.Data, ShouldEqual, 4200)
+				So(metric.Tags["exited"], ShouldEqual, "true")
+				So(metric.Tags["job"], ShouldEqual, "batch")
+			} else {
+				So(metric.Tags, ShouldNotContainKey, "exited")
+			}
+		}
+		So(found, ShouldBeTrue)
+		// statistics of exited container are not read again
+		mc.AssertNotCalled(t, "InspectContainer", mock.Anything)
+		// labels of exited container are not changed by tags
+		So(snapshot.Specification.Labels, ShouldNotContainKey, "exited")
+
+		// final metrics are emitted only once
+		metrics, err = dockerPlg.CollectMetrics([]plugin.Metric{cpuMt})
+		So(err, ShouldBeNil)
+		for _, metric := range metrics {
+			So(metric.Namespace[2].Value, ShouldNotEqual, mockExitedShortID)
+		}
+	})
+
+	Convey("successful collect metrics of docker daemon", t, func() {
+		mockDaemon := &container.DaemonInfo{
+			ContainersRunning: 3,
+			ContainersStopped: 2,
+			Images:            12,
+			Goroutines:        64,
+			NCPU:              8,
+			Warnings:          1,
+			Version:           "17.03.1-ce",
+		}
+		mc := new(ClientMock)
+		mc.On("GetDaemonInfo").Return(mockDaemon, nil)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		daemonMt := func(name string) plugin.Metric {
+			return plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME, "daemon", name),
+				Config:    metricConf,
+			}
+		}
+
+		Convey("successful when only metrics of docker daemon are requested", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{daemonMt("containers_running"), daemonMt("version")})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(metrics[0].Data, ShouldEqual, 3)
+			So(metrics[1].Data, ShouldEqual, "17.03.1-ce")
+			// containers are not listed when they are not needed
+			mc.AssertNotCalled(t, "ListContainersAsMap")
+		})
+		Convey("successful together with metrics of containers", func() {
+			cpuMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+				Config: metricConf,
+			}
+			cpuMt.Namespace[2].Value = mockDockerID
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{daemonMt("images"), cpuMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(metrics[0].Namespace.Strings(), ShouldResemble, []string{PLUGIN_VENDOR, PLUGIN_NAME, "daemon", "images"})
+			So(metrics[0].Data, ShouldEqual, 12)
+			So(metrics[1].Namespace[2].Value, ShouldEqual, mockDockerID)
+		})
+		Convey("return an error when docker daemon is not available", func() {
+			mc := new(ClientMock)
+			mc.On("GetDaemonInfo").Return(nil, errors.New("Cannot connect to the Docker daemon"))
+			dockerPlg.client = mc
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{daemonMt("images")})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+		})
+	})
+
+	Convey("successful collect metrics of docker images", t, func() {
+		mockImages := map[string]container.ImageInventory{
+			"nginx:1.13":             {ID: "sha256:e4e6d42c70b3", Size: 108958610, Containers: 2},
+			"localhost:5000/app:2.0": {ID: "sha256:0f4e1b3c9d2a", Size: 4096, Unused: 1},
+		}
+		mc := new(ClientMock)
+		mc.On("GetImagesInventory").Return(mockImages, nil)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		imageMt := func(image, name string) plugin.Metric {
+			mt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME, "images").
+					AddDynamicElement("image_name", "a repo tag of docker image or its short id when it has no tag").
+					AddStaticElement(name),
+				Config: metricConf,
+			}
+			mt.Namespace[3].Value = image
+			return mt
+		}
+
+		Convey("successful when specified image exists", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{imageMt("nginx:1_13", "containers")})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace.Strings(), ShouldResemble, []string{PLUGIN_VENDOR, PLUGIN_NAME, "images", "nginx:1_13", "containers"})
+			So(metrics[0].Data, ShouldEqual, 2)
+			// containers are not listed when they are not needed
+			mc.AssertNotCalled(t, "ListContainersAsMap")
+		})
+		Convey("successful when image name is requested as an asterisk", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{imageMt("*", "unused")})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			names := []string{}
+			for _, metric := range metrics {
+				names = append(names, metric.Namespace[3].Value)
+			}
+			// not allowed characters in image name are replaced
+			So(names, ShouldContain, "nginx:1_13")
+			So(names, ShouldContain, "localhost:5000_app:2_0")
+		})
+		Convey("return an error when specified image is invalid", func() {
+			mt := imageMt("postgres:9_6", "size")
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given image name is invalid (no such docker image)", strings.Join(mt.Namespace.Strings(), "/")))
+		})
+	})
+
+	Convey("successful tagging metrics of containers with their image", t, func() {
+		mockImageID := "sha256:e4e6d42c70b3f79c5b67b9b1df5c8e5a1c7d6a2b3c4d5e6f7a8b9c0d1e2f3a4b"
+		mockDigest := "sha256:9fca103a62af6db7f188ac3376c60927db41f88b8d2354bf02d2290a672dc425"
+		mockContainers := map[string]*container.ContainerData{
+			mockDockerID: {
+				ID:    mockDockerID,
+				Stats: container.NewStatistics(),
+				Specification: container.Specification{
+					Image:       "nginx",
+					ImageID:     mockImageID,
+					ImageDigest: mockDigest,
+					Labels:      map[string]string{"lkey1": "lval1"},
+				},
+			},
+			mockDockerHost: {ID: "/", Stats: container.NewStatistics()},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		mockMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+			Config: metricConf,
+		}
+		mockMt.Namespace[2].Value = "*"
+
+		metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+		So(err, ShouldBeNil)
+		So(len(metrics), ShouldEqual, 2)
+		for _, metric := range metrics {
+			if metric.Namespace[2].Value == mockDockerHost {
+				So(metric.Tags, ShouldNotContainKey, "image_id")
+				continue
+			}
+			So(metric.Tags["image_id"], ShouldEqual, mockImageID)
+			So(metric.Tags["image_digest"], ShouldEqual, mockDigest)
+			So(metric.Tags["lkey1"], ShouldEqual, "lval1")
+		}
+		// labels of the container are not changed by tagging
+		So(mockContainers[mockDockerID].Specification.Labels, ShouldNotContainKey, "image_id")
+	})
+
+	Convey("successful collect metrics describing docker networks of the host", t, func() {
+		mockNetworks := []docker.Network{
+			{
+				Name:   "bridge",
+				Driver: "bridge",
+				Scope:  "local",
+				IPAM:   docker.IPAMOptions{Config: []docker.IPAMConfig{{Subnet: "172.17.0.0/16", Gateway: "172.17.0.1"}}},
+				Containers: map[string]docker.Endpoint{
+					"a26c852ce22c": {IPv4Address: "172.17.0.2/16"},
+				},
+			},
+			{
+				Name:   "my.net",
+				Driver: "overlay",
+				Scope:  "swarm",
+				IPAM:   docker.IPAMOptions{Config: []docker.IPAMConfig{{Subnet: "10.0.0.0/29", Gateway: "10.0.0.1"}}},
+			},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("ListNetworks").Return(mockNetworks, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		mockMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElement("networks").
+				AddDynamicElement("network_name", "a name of docker network").
+				AddStaticElement("allocated"),
+			Config: metricConf,
+		}
+		mockMt.Namespace[2].Value = mockDockerHost
+
+		Convey("successful when specified network exists", func() {
+			mockMt.Namespace[4].Value = "bridge"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, 2)
+		})
+		Convey("successful when specified network name contains not allowed characters", func() {
+			mockMt.Namespace[4].Value = "my_net"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, 1)
+		})
+		Convey("successful when network name is requested as an asterisk", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			names := []string{}
+			for _, metric := range metrics {
+				names = append(names, metric.Namespace[4].Value)
+			}
+			// not allowed characters in network name are replaced
+			So(names, ShouldContain, "my_net")
+			So(names, ShouldContain, "bridge")
+		})
+		Convey("successful when docker_id is requested as an asterisk, inventory is reported only for host", func() {
+			mockMt.Namespace[2].Value = "*"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			for _, metric := range metrics {
+				So(metric.Namespace[2].Value, ShouldEqual, mockDockerHost)
+			}
+		})
+		Convey("return an error when specified network is invalid", func() {
+			mockMt.Namespace[4].Value = "host"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given network name is invalid (no such docker network)", strings.Join(mockMt.Namespace.Strings(), "/")))
+		})
+	})
+
+	Convey("successful collect metrics describing disk usage of docker objects", t, func() {
+		mockDiskUsage := &container.DiskUsage{
+			Images:  container.DiskUsageSummary{Count: 2, Active: 1, Total: 1000, Reclaimable: 500},
+			Volumes: container.DiskUsageSummary{Count: 2, Active: 1, Total: 4608, Reclaimable: 512},
+			Volume: map[string]container.VolumeUsage{
+				"pgdata":   {Size: 4096, RefCount: 1},
+				"my.cache": {Size: 512, RefCount: 0},
+			},
+		}
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("GetDiskUsage").Return(mockDiskUsage, nil)
+		getters = MockGetters
+		dockerPlg.client = mc
+
+		mockTotalMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("disk_usage", "images", "reclaimable"),
+			Config: metricConf,
+		}
+		mockTotalMt.Namespace[2].Value = mockDockerHost
+
+		mockVolumeMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("disk_usage", "volume").
+				AddDynamicElement("volume_name", "a name of docker volume").
+				AddStaticElement("size"),
+			Config: metricConf,
+		}
+		mockVolumeMt.Namespace[2].Value = mockDockerHost
+
+		Convey("successful for totals of docker objects", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockTotalMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace, ShouldResemble, mockTotalMt.Namespace)
+			So(metrics[0].Data, ShouldEqual, 500)
+		})
+		Convey("successful when specified volume exists", func() {
+			mockVolumeMt.Namespace[5].Value = "pgdata"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockVolumeMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Data, ShouldEqual, 4096)
+		})
+		Convey("successful when volume name is requested as an asterisk", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockVolumeMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			names := []string{}
+			for _, metric := range metrics {
+				names = append(names, metric.Namespace[5].Value)
 			}
+			// not allowed characters in volume name are replaced
+			So(names, ShouldContain, "my_cache")
+			So(names, ShouldContain, "pgdata")
+		})
+		Convey("successful when docker_id is requested as an asterisk, disk usage is reported only for host", func() {
+			mockTotalMt.Namespace[2].Value = "*"
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockTotalMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace[2].Value, ShouldEqual, mockDockerHost)
 		})
+		Convey("return an error when specified volume is invalid", func() {
+			mockVolumeMt.Namespace[5].Value = "postgres"
 
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockVolumeMt})
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given volume name is invalid (no such docker volume)", strings.Join(mockVolumeMt.Namespace.Strings(), "/")))
+		})
 	})
 
 	Convey("successful collect metrics for specified dynamic metric", t, func() {
@@ -262,6 +1175,7 @@ func TestCollectMetrics(t *testing.T) {
 		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
 		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
 		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
 		mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
 		getters = MockGetters
 		dockerPlg.client = mc
@@ -423,6 +1337,223 @@ func TestCollectMetrics(t *testing.T) {
 			})
 		})
 
+		Convey("for specific dynamic elements: docker_id and counter", func() {
+			mockMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "connection", "snmp", "tcp").
+					AddDynamicElement("counter", "a name of TCP counter").
+					AddStaticElement("value"),
+				Config: metricConf,
+			}
+			// specify docker_id of requested metric type
+			mockMt.Namespace[2].Value = mockDockerID
+
+			Convey("successful when specified counter exists", func() {
+				mockMt.Namespace[7].Value = "RetransSegs"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 1)
+				So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+				So(metrics[0].Data, ShouldEqual, 1111)
+			})
+			Convey("successful when counter is requested as an asterisk", func() {
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 2)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("return an error when specified counter is invalid", func() {
+				mockMt.Namespace[7].Value = "RetransSegs_invalid"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given counter is invalid (no stats for this counter)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+		})
+
+		Convey("for specific dynamic elements: docker_id and port", func() {
+			mockMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "connection", "tcp", "listen_backlog").
+					AddDynamicElement("port", "a local port of listening socket").
+					AddStaticElement("value"),
+				Config: metricConf,
+			}
+			// specify docker_id of requested metric type
+			mockMt.Namespace[2].Value = mockDockerID
+
+			Convey("successful when specified port exists", func() {
+				mockMt.Namespace[7].Value = "80"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 1)
+				So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+				So(metrics[0].Data, ShouldEqual, 1111)
+			})
+			Convey("successful when port is requested as an asterisk", func() {
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 2)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("return an error when specified port is invalid", func() {
+				mockMt.Namespace[7].Value = "8080"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given port is invalid (no listening socket on this port)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+		})
+
+		Convey("for specific dynamic elements: docker_id and port of inventory", func() {
+			mockMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "connection", "ports", "per_port").
+					AddDynamicElement("port", "a port number and protocol, e.g. 80_tcp").
+					AddStaticElement("published"),
+				Config: metricConf,
+			}
+			// specify docker_id of requested metric type
+			mockMt.Namespace[2].Value = mockDockerID
+
+			Convey("successful when specified port exists", func() {
+				mockMt.Namespace[7].Value = "443_tcp"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 1)
+				So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+				So(metrics[0].Data, ShouldEqual, 1)
+			})
+			Convey("successful when port is requested as an asterisk", func() {
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 2)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("return an error when specified port is invalid", func() {
+				mockMt.Namespace[7].Value = "22_tcp"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given port is invalid (port is neither listened on nor published)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+		})
+
+		Convey("for specific dynamic elements: docker_id and peer", func() {
+			mockMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "connection", "peers").
+					AddDynamicElement("peer", "a remote endpoint (address or network, optionally with port) or 'other' for aggregate").
+					AddStaticElement("established"),
+				Config: metricConf,
+			}
+			// specify docker_id of requested metric type
+			mockMt.Namespace[2].Value = mockDockerID
+
+			Convey("successful when specified peer exists", func() {
+				mockMt.Namespace[6].Value = "10_0_0_1"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 1)
+				So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+				So(metrics[0].Data, ShouldEqual, 1111)
+			})
+			Convey("successful when peer is requested as an asterisk", func() {
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 2)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("return an error when specified peer is invalid", func() {
+				mockMt.Namespace[6].Value = "10_0_0_2"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given peer is invalid (no connections to this peer)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+		})
+
+		Convey("for specific dynamic elements: docker_id, network_interface and handle", func() {
+			mockMt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "network").
+					AddDynamicElement("network_interface", "a name of network interface or 'total' for aggregate").
+					AddStaticElement("qdisc").
+					AddDynamicElement("handle", "a handle of qdisc or class, e.g. 1:10").
+					AddStaticElement("drops"),
+				Config: metricConf,
+			}
+			// specify docker_id of requested metric type
+			mockMt.Namespace[2].Value = mockDockerID
+
+			Convey("successful when specified network interface and handle exist", func() {
+				mockMt.Namespace[5].Value = "eth0"
+				mockMt.Namespace[7].Value = "1:10"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 1)
+				So(metrics[0].Namespace, ShouldResemble, mockMt.Namespace)
+				So(metrics[0].Data, ShouldEqual, 22)
+			})
+			Convey("successful when handle is requested as an asterisk", func() {
+				mockMt.Namespace[5].Value = "eth0"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 2)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("successful when network interface and handle are requested as an asterisk", func() {
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldBeNil)
+				So(len(metrics), ShouldEqual, 3)
+				for _, metric := range metrics {
+					So(metric.Namespace.Strings(), ShouldNotContain, "*")
+				}
+			})
+			Convey("return an error when specified network interface is invalid", func() {
+				mockMt.Namespace[5].Value = "eth1"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given network interface is invalid (no qdisc stats for this net interface)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+			Convey("return an error when specified handle is invalid", func() {
+				mockMt.Namespace[5].Value = "eth0"
+				mockMt.Namespace[7].Value = "2:0"
+
+				metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{mockMt})
+				So(err, ShouldNotBeNil)
+				So(metrics, ShouldBeEmpty)
+				So(err.Error(), ShouldEqual, fmt.Sprintf("In metric %s the given handle is invalid (no stats for this qdisc)", strings.Join(mockMt.Namespace.Strings(), "/")))
+			})
+		})
+
 		Convey("for specific dynamic elements: docker_id and label_key", func() {
 			mockMt := plugin.Metric{
 				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
@@ -458,6 +1589,383 @@ func TestCollectMetrics(t *testing.T) {
 	})
 }
 
+func TestGetPublishedPorts(t *testing.T) {
+	Convey("get ports published by the container", t, func() {
+
+		Convey("successful when container has no network settings", func() {
+			So(getPublishedPorts(&docker.Container{}), ShouldBeEmpty)
+		})
+
+		Convey("successful when ports are bound or requested to be bound", func() {
+			cont := &docker.Container{
+				NetworkSettings: &docker.NetworkSettings{
+					Ports: map[docker.Port][]docker.PortBinding{
+						"80/tcp":   {{HostIP: "0.0.0.0", HostPort: "8080"}},
+						"9090/tcp": {},
+					},
+				},
+				HostConfig: &docker.HostConfig{
+					PortBindings: map[docker.Port][]docker.PortBinding{
+						"80/tcp": {{HostPort: "8080"}},
+						"53/udp": {{HostPort: "5353"}},
+					},
+				},
+			}
+			ports := getPublishedPorts(cont)
+			So(len(ports), ShouldEqual, 2)
+			So(ports, ShouldContain, "80_tcp")
+			So(ports, ShouldContain, "53_udp")
+			// exposed port without binding is not published
+			So(ports, ShouldNotContain, "9090_tcp")
+		})
+	})
+}
+
+func TestSetConfigSpec(t *testing.T) {
+	Convey("set configuration of the container in its specification", t, func() {
+
+		Convey("successful when container has no configuration", func() {
+			spec := container.Specification{}
+			setConfigSpec(&spec, &docker.Container{Name: "/web"}, nil)
+			So(spec.Name, ShouldEqual, "web")
+			So(spec.Limits, ShouldResemble, container.LimitsSpec{})
+		})
+
+		Convey("successful when container has configuration", func() {
+			cont := &docker.Container{
+				Name:   "/web",
+				Mounts: []docker.Mount{{Source: "/data", Destination: "/var/lib/data"}},
+				Config: &docker.Config{
+					Cmd:        []string{"nginx", "-g", "daemon off;"},
+					Entrypoint: []string{"/docker-entrypoint.sh"},
+				},
+				HostConfig: &docker.HostConfig{
+					RestartPolicy: docker.RestartPolicy{Name: "on-failure", MaximumRetryCount: 5},
+					LogConfig:     docker.LogConfig{Type: "json-file"},
+					Memory:        536870912,
+					MemorySwap:    -1,
+					CPUQuota:      50000,
+					CPUSetCPUs:    "0-1",
+					PidsLimit:     100,
+					BlkioWeight:   500,
+					Ulimits:       []docker.ULimit{{Name: "nofile", Soft: 1024, Hard: 4096}},
+					PortBindings: map[docker.Port][]docker.PortBinding{
+						"80/tcp": {{HostPort: "8080"}},
+					},
+				},
+			}
+			spec := container.Specification{}
+			setConfigSpec(&spec, cont, &container.HostConfigExtras{NanoCpus: 1500000000, Runtime: "runc"})
+			So(spec.Name, ShouldEqual, "web")
+			So(spec.Command, ShouldEqual, "nginx -g daemon off;")
+			So(spec.Entrypoint, ShouldEqual, "/docker-entrypoint.sh")
+			So(spec.RestartPolicy, ShouldEqual, "on-failure")
+			So(spec.RestartMaxRetries, ShouldEqual, 5)
+			So(spec.LogDriver, ShouldEqual, "json-file")
+			So(spec.Runtime, ShouldEqual, "runc")
+			So(spec.Mounts, ShouldEqual, 1)
+			So(spec.PublishedPorts, ShouldEqual, 1)
+			So(spec.Limits, ShouldResemble, container.LimitsSpec{
+				Memory:      536870912,
+				MemorySwap:  -1,
+				NanoCpus:    1500000000,
+				CpuQuota:    50000,
+				CpusetCpus:  "0-1",
+				PidsLimit:   100,
+				BlkioWeight: 500,
+				Ulimits:     map[string]container.UlimitSpec{"nofile": {Soft: 1024, Hard: 4096}},
+			})
+		})
+	})
+}
+
+func TestGetStatusSpec(t *testing.T) {
+	Convey("get status of the container as presented by docker ps", t, func() {
+		now := time.Unix(1500000000, 0)
+
+		Convey("successful when container is running", func() {
+			cont := &docker.Container{State: docker.State{Running: true, StartedAt: now.Add(-4 * time.Minute)}}
+			So(getStatusSpec(cont, now), ShouldEqual, "Up 4 minutes")
+
+			// status follows the time without inspecting the container again
+			So(getStatusSpec(cont, now.Add(time.Minute)), ShouldEqual, "Up 5 minutes")
+		})
+
+		Convey("successful when container is paused or has healthcheck", func() {
+			cont := &docker.Container{State: docker.State{Running: true, Paused: true, StartedAt: now.Add(-2 * time.Hour)}}
+			So(getStatusSpec(cont, now), ShouldEqual, "Up 2 hours (Paused)")
+
+			cont = &docker.Container{State: docker.State{Running: true, StartedAt: now.Add(-time.Hour), Health: docker.Health{Status: "healthy"}}}
+			So(getStatusSpec(cont, now), ShouldEqual, "Up About an hour (healthy)")
+
+			cont.State.Health.Status = "starting"
+			So(getStatusSpec(cont, now), ShouldEqual, "Up About an hour (health: starting)")
+		})
+
+		Convey("successful when container is not running", func() {
+			cont := &docker.Container{State: docker.State{ExitCode: 3, StartedAt: now.Add(-time.Hour), FinishedAt: now.Add(-10 * time.Second)}}
+			So(getStatusSpec(cont, now), ShouldEqual, "Exited (3) 10 seconds ago")
+			So(getStatusSpec(&docker.Container{}, now), ShouldEqual, "Created")
+			So(getStatusSpec(&docker.Container{State: docker.State{Dead: true, StartedAt: now}}, now), ShouldEqual, "Dead")
+		})
+	})
+}
+
+func TestGetStateSpec(t *testing.T) {
+	Convey("get lifecycle state of the container", t, func() {
+		now := time.Unix(1500000000, 0)
+
+		Convey("successful when container is running", func() {
+			cont := &docker.Container{
+				State:        docker.State{Running: true, StartedAt: now.Add(-90 * time.Second)},
+				RestartCount: 2,
+			}
+			state := getStateSpec(cont, now)
+			So(state.Code, ShouldEqual, 1)
+			So(state.Running, ShouldEqual, 1)
+			So(state.Paused, ShouldEqual, 0)
+			So(state.Uptime, ShouldEqual, 90)
+			So(state.RestartCount, ShouldEqual, 2)
+			So(state.FinishedAt, ShouldEqual, 0)
+		})
+
+		Convey("successful when container is paused", func() {
+			cont := &docker.Container{
+				State: docker.State{Running: true, Paused: true, StartedAt: now.Add(-time.Minute)},
+			}
+			state := getStateSpec(cont, now)
+			So(state.Code, ShouldEqual, 2)
+			So(state.Running, ShouldEqual, 1)
+			So(state.Paused, ShouldEqual, 1)
+		})
+
+		Convey("successful when container has been killed because of out of memory", func() {
+			cont := &docker.Container{
+				State: docker.State{
+					OOMKilled:  true,
+					ExitCode:   137,
+					StartedAt:  now.Add(-time.Hour),
+					FinishedAt: now.Add(-time.Minute),
+				},
+			}
+			state := getStateSpec(cont, now)
+			So(state.Code, ShouldEqual, 5)
+			So(state.Running, ShouldEqual, 0)
+			So(state.OOMKilled, ShouldEqual, 1)
+			So(state.ExitCode, ShouldEqual, 137)
+			So(state.Uptime, ShouldEqual, 0)
+			So(state.FinishedAt, ShouldEqual, now.Add(-time.Minute).Unix())
+		})
+
+		Convey("successful when container is being removed", func() {
+			cont := &docker.Container{
+				State: docker.State{RemovalInProgress: true, StartedAt: now.Add(-time.Hour)},
+			}
+			So(getStateSpec(cont, now).Code, ShouldEqual, 4)
+		})
+	})
+}
+
+func TestGetHealthSpec(t *testing.T) {
+	Convey("get status of the container's healthcheck", t, func() {
+		start := time.Unix(1500000000, 0)
+
+		Convey("successful when container has no healthcheck", func() {
+			So(getHealthSpec(&docker.Container{}), ShouldResemble, container.HealthSpec{})
+		})
+
+		Convey("successful when container has healthcheck with probes in log", func() {
+			cont := &docker.Container{
+				State: docker.State{
+					Health: docker.Health{
+						Status:        "unhealthy",
+						FailingStreak: 2,
+						Log: []docker.HealthCheck{
+							{Start: start, End: start.Add(100 * time.Millisecond), ExitCode: 0},
+							{Start: start.Add(30 * time.Second), End: start.Add(31 * time.Second), ExitCode: 1},
+							{Start: start.Add(60 * time.Second), End: start.Add(62500 * time.Millisecond), ExitCode: 2},
+						},
+					},
+				},
+			}
+			health := getHealthSpec(cont)
+			So(health.Status, ShouldEqual, 3)
+			So(health.FailingStreak, ShouldEqual, 2)
+			So(health.Probes, ShouldEqual, 3)
+			So(health.FailedProbes, ShouldEqual, 2)
+			So(health.LastExitCode, ShouldEqual, 2)
+			So(health.LastDuration, ShouldAlmostEqual, 2.5)
+		})
+
+		Convey("successful when healthcheck is starting", func() {
+			cont := &docker.Container{State: docker.State{Health: docker.Health{Status: "starting"}}}
+			health := getHealthSpec(cont)
+			So(health.Status, ShouldEqual, 1)
+			So(health.Probes, ShouldEqual, 0)
+			So(health.LastDuration, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestGetNetworksInventory(t *testing.T) {
+	Convey("get inventory of docker networks", t, func() {
+
+		Convey("successful when network has IPv4 subnet", func() {
+			networks := []docker.Network{{
+				Name:   "my_bridge",
+				Driver: "bridge",
+				Scope:  "local",
+				IPAM: docker.IPAMOptions{Config: []docker.IPAMConfig{
+					{Subnet: "192.168.0.0/28", Gateway: "192.168.0.1", AuxAddress: map[string]string{"router": "192.168.0.2"}},
+					{Subnet: "fd00::/64", Gateway: "fd00::1"},
+				}},
+				Containers: map[string]docker.Endpoint{
+					"a26c852ce22c": {IPv4Address: "192.168.0.3/28", IPv6Address: "fd00::3/64"},
+					"b4a1e9f07c3d": {IPv4Address: "192.168.0.4/28"},
+				},
+			}}
+			inventory := getNetworksInventory(networks)
+			So(inventory, ShouldContainKey, "my_bridge")
+			stats := inventory["my_bridge"]
+			So(stats.Driver, ShouldEqual, "bridge")
+			So(stats.Scope, ShouldEqual, "local")
+			So(stats.Containers, ShouldEqual, 2)
+			// IPv6 subnets are not taken into account
+			So(stats.SubnetSize, ShouldEqual, 16)
+			// gateway, auxiliary address and addresses of containers
+			So(stats.Allocated, ShouldEqual, 4)
+			// network and broadcast addresses cannot be allocated
+			So(stats.Utilization, ShouldAlmostEqual, 4.0/14.0)
+		})
+
+		Convey("successful when network has IP range configured", func() {
+			networks := []docker.Network{{
+				Name: "ranged",
+				IPAM: docker.IPAMOptions{Config: []docker.IPAMConfig{
+					{Subnet: "10.1.0.0/16", IPRange: "10.1.2.0/30", Gateway: "10.1.0.1"},
+				}},
+				Containers: map[string]docker.Endpoint{
+					"a26c852ce22c": {IPv4Address: "10.1.2.1/16"},
+				},
+			}}
+			stats := getNetworksInventory(networks)["ranged"]
+			So(stats.SubnetSize, ShouldEqual, 4)
+			// gateway is out of IP range
+			So(stats.Allocated, ShouldEqual, 1)
+			So(stats.Utilization, ShouldAlmostEqual, 0.25)
+		})
+
+		Convey("successful when network has no IPv4 subnet", func() {
+			networks := []docker.Network{{Name: "host", Driver: "host", Scope: "local"}}
+			stats := getNetworksInventory(networks)["host"]
+			So(stats.Driver, ShouldEqual, "host")
+			So(stats.SubnetSize, ShouldEqual, 0)
+			So(stats.Allocated, ShouldEqual, 0)
+			So(stats.Utilization, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestGetListOptions(t *testing.T) {
+	Convey("get options of listing containers", t, func() {
+
+		Convey("successful when configuration is not set", func() {
+			So(getListOptions(plugin.Config{}), ShouldResemble, container.ListOptions{})
+		})
+
+		Convey("successful when configuration is set", func() {
+			cfg := plugin.Config{
+				"all_containers":     true,
+				"container_statuses": "running, exited,,dead",
+			}
+			So(getListOptions(cfg), ShouldResemble, container.ListOptions{All: true, Statuses: []string{"running", "exited", "dead"}})
+		})
+
+		Convey("successful when computing sizes of containers is enabled", func() {
+			cfg := plugin.Config{
+				"container_size":          true,
+				"container_size_interval": "30m",
+			}
+			So(getListOptions(cfg).SizeInterval, ShouldEqual, 30*time.Minute)
+		})
+
+		Convey("default interval is used when the given interval is invalid", func() {
+			cfg := plugin.Config{
+				"container_size":          true,
+				"container_size_interval": "often",
+			}
+			So(getListOptions(cfg).SizeInterval, ShouldEqual, defaultSizeInterval)
+		})
+
+		Convey("successful when tracking of containers by docker events is enabled", func() {
+			cfg := plugin.Config{
+				"container_events": true,
+			}
+			So(getListOptions(cfg).ResyncInterval, ShouldEqual, defaultResyncInterval)
+
+			cfg["container_resync_interval"] = "1h"
+			So(getListOptions(cfg).ResyncInterval, ShouldEqual, time.Hour)
+
+			cfg["container_resync_interval"] = "-1m"
+			So(getListOptions(cfg).ResyncInterval, ShouldEqual, defaultResyncInterval)
+		})
+
+		Convey("sizes of containers are not computed when it is disabled", func() {
+			cfg := plugin.Config{
+				"container_size":          false,
+				"container_size_interval": "30m",
+			}
+			So(getListOptions(cfg).SizeInterval, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	Convey("get configuration of TLS for docker endpoint", t, func() {
+
+		Convey("TLS is disabled when no certificate is given", func() {
+			opts, err := getTLSConfig(plugin.Config{"tls_ca": "", "tls_cert": "", "tls_key": "", "tls_verify": true})
+			So(err, ShouldBeNil)
+			So(opts.Enabled(), ShouldBeFalse)
+		})
+
+		Convey("certificate of docker endpoint is verified by default", func() {
+			opts, _ := getTLSConfig(plugin.Config{"tls_ca": "/etc/docker/ca.pem"})
+			So(opts.Verify, ShouldBeTrue)
+
+			opts, _ = getTLSConfig(plugin.Config{"tls_ca": "/etc/docker/ca.pem", "tls_verify": false})
+			So(opts.Verify, ShouldBeFalse)
+		})
+
+		Convey("return an error when certificates cannot be loaded", func() {
+			_, err := getTLSConfig(plugin.Config{"tls_ca": "/nonexistent/ca.pem"})
+			So(err, ShouldNotBeNil)
+
+			_, err = getTLSConfig(plugin.Config{"tls_cert": "/nonexistent/cert.pem"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGetPeersConfig(t *testing.T) {
+	Convey("get configuration of aggregating connections by remote endpoint", t, func() {
+
+		Convey("successful when configuration is not set", func() {
+			So(getPeersConfig(plugin.Config{}), ShouldResemble, defaultPeersConfig)
+		})
+
+		Convey("successful when configuration is set", func() {
+			cfg := plugin.Config{
+				"peers_top_n":       int64(5),
+				"peers_ipv4_prefix": int64(24),
+				"peers_ipv6_prefix": int64(64),
+				"peers_by_port":     true,
+			}
+			So(getPeersConfig(cfg), ShouldResemble, peersConfig{topN: 5, ipv4Prefix: 24, ipv6Prefix: 64, byPort: true})
+		})
+	})
+}
+
 func TestCreateMetricNamespace(t *testing.T) {
 	Convey("create metric namespace", t, func() {
 		nscreator := nsCreator{}
@@ -0,0 +1,134 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+const (
+	// minReconnectBackoff is delay of the first attempt to reconnect docker daemon which is down
+	minReconnectBackoff = time.Second
+	// maxReconnectBackoff limits delay between attempts to reconnect docker daemon, the delay is doubled after each failure
+	maxReconnectBackoff = 5 * time.Minute
+)
+
+// connection holds state of connection to docker daemon, the zero value means that docker daemon is up
+type connection struct {
+	down              bool          // docker daemon does not respond, only metrics of host are collected
+	backoff           time.Duration // delay of the next attempt to reconnect docker daemon
+	retryAt           time.Time     // time of the next attempt to reconnect docker daemon
+	reconnects        uint64        // number of successful reconnections
+	reconnectFailures uint64        // number of failed attempts to reconnect
+}
+
+// disconnected marks docker daemon as down when the given error is caused by failed connection to it,
+// it returns false for other errors (e.g. docker API reports that container does not exist)
+func (c *collector) disconnected(err error) bool {
+	if !container.IsConnectionError(err) {
+		return false
+	}
+
+	if !c.conn.down {
+		log.WithFields(log.Fields{
+			"block":    "connection",
+			"function": "disconnected",
+		}).Warnf("Docker daemon %s is down, only metrics of host are collected: %s", c.conf["endpoint"], err)
+		c.conn.down = true
+		c.conn.backoff = minReconnectBackoff
+		c.conn.retryAt = time.Now().Add(c.conn.backoff)
+	}
+
+	return true
+}
+
+// reconnect initializes docker client again when docker daemon is down and the backoff has elapsed,
+// it returns true when docker daemon is up
+func (c *collector) reconnect() bool {
+	if !c.conn.down {
+		return true
+	}
+	if time.Now().Before(c.conn.retryAt) {
+		return false
+	}
+
+	old := c.client
+	if err := initClient(c, c.conf["endpoint"], c.tls); err != nil {
+		c.conn.reconnectFailures++
+		c.conn.backoff *= 2
+		if c.conn.backoff > maxReconnectBackoff {
+			c.conn.backoff = maxReconnectBackoff
+		}
+		c.conn.retryAt = time.Now().Add(c.conn.backoff)
+		log.WithFields(log.Fields{
+			"block":    "connection",
+			"function": "reconnect",
+		}).Warnf("Unable to reconnect docker daemon %s, next attempt in %s: %s", c.conf["endpoint"], c.conn.backoff, err)
+		return false
+	}
+
+	// events are monitored by the previous client until it is closed
+	if old != nil {
+		old.Close()
+	}
+	c.conn.down = false
+	c.conn.reconnects++
+	log.WithFields(log.Fields{
+		"block":    "connection",
+		"function": "reconnect",
+	}).Infof("Docker daemon %s is reconnected", c.conf["endpoint"])
+
+	if c.exited != nil {
+		if err := c.client.AddEventHandler(c.exited.handle); err != nil {
+			// only log error, metrics of containers which are listed are still available
+			log.WithFields(log.Fields{
+				"block":    "connection",
+				"function": "AddEventHandler",
+			}).Errorf("Unable to track exited containers: %s", err)
+		}
+	}
+
+	return true
+}
+
+// connectionStats returns state of connection to docker daemon exposed as metrics of docker daemon
+func (c *collector) connectionStats() container.DaemonConnection {
+	stats := container.DaemonConnection{
+		Reconnects:        c.conn.reconnects,
+		ReconnectFailures: c.conn.reconnectFailures,
+	}
+	if !c.conn.down {
+		stats.Up = 1
+	}
+	return stats
+}
+
+// hostContainers returns containers map holding only host, it is collected while docker daemon is down
+func hostContainers() map[string]*container.ContainerData {
+	return map[string]*container.ContainerData{
+		"root": {ID: "/", Stats: container.NewStatistics()},
+	}
+}
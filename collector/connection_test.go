@@ -0,0 +1,195 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+	. "github.com/intelsdi-x/snap-plugin-collector-docker/mocks"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+)
+
+func TestReconnect(t *testing.T) {
+	Convey("Reconnect docker daemon which is down", t, func() {
+		getters = MockGetters
+		mc := new(ClientMock)
+		mc.On("ListContainersAsMap").Return(nil, docker.ErrConnectionRefused)
+		mc.On("GetDaemonInfo").Return(nil, docker.ErrConnectionRefused)
+		mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+		mc.On("Close").Return(nil)
+
+		dockerPlg := &collector{
+			client: mc,
+			mounts: map[string]string{},
+			conf:   map[string]string{"endpoint": "unix:///var/run/docker.sock"},
+		}
+
+		// docker client is created again only when docker daemon is reconnected
+		newClients := []container.DockerClientInterface{}
+		newClientErr := error(docker.ErrConnectionRefused)
+		defaultNewDockerClient := newDockerClient
+		newDockerClient = func(endpoint string, tlsOpts container.TLSOptions) (container.DockerClientInterface, error) {
+			if newClientErr != nil {
+				return nil, newClientErr
+			}
+			nc := new(ClientMock)
+			nc.On("GetDockerParams", mock.Anything).Return(map[string]string{"DockerRootDir": "/var/lib/docker", "Driver": "overlay2"}, nil)
+			nc.On("GetDockerVersion").Return([]int{1, 13})
+			nc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+			nc.On("GetDaemonInfo").Return(&container.DaemonInfo{Images: 12}, nil)
+			nc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			nc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+			nc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+			nc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			nc.On("AddEventHandler", mock.Anything).Return(nil)
+			newClients = append(newClients, nc)
+			return nc, nil
+		}
+		Reset(func() {
+			newDockerClient = defaultNewDockerClient
+		})
+
+		cpuMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+			Config: metricConf,
+		}
+		cpuMt.Namespace[2].Value = "*"
+		daemonMt := func(name string) plugin.Metric {
+			return plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME, "daemon", name),
+				Config:    metricConf,
+			}
+		}
+		collect := func() map[string]interface{} {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{daemonMt("up"), daemonMt("reconnects"), daemonMt("reconnect_failures"), daemonMt("images"), cpuMt})
+			So(err, ShouldBeNil)
+			values := map[string]interface{}{}
+			for _, metric := range metrics {
+				values[metric.Namespace.Strings()[2]+"/"+metric.Namespace.Strings()[3]] = metric.Data
+			}
+			return values
+		}
+
+		Convey("metrics of host are collected while docker daemon is down", func() {
+			values := collect()
+			So(dockerPlg.conn.down, ShouldBeTrue)
+			So(values["daemon/up"], ShouldEqual, 0)
+			So(values["daemon/reconnects"], ShouldEqual, 0)
+			So(values, ShouldNotContainKey, "daemon/images")
+			So(values, ShouldContainKey, "root/stats")
+			So(values, ShouldNotContainKey, mockDockerID+"/stats")
+			So(dockerPlg.conn.backoff, ShouldEqual, minReconnectBackoff)
+
+			Convey("docker daemon is not reconnected before the backoff elapses", func() {
+				newClientErr = nil
+				collect()
+				So(newClients, ShouldBeEmpty)
+				So(dockerPlg.conn.down, ShouldBeTrue)
+			})
+
+			Convey("backoff is doubled after each failed attempt to reconnect", func() {
+				dockerPlg.conn.retryAt = time.Now()
+				values := collect()
+				So(values["daemon/up"], ShouldEqual, 0)
+				So(values["daemon/reconnect_failures"], ShouldEqual, 1)
+				So(dockerPlg.conn.backoff, ShouldEqual, 2*minReconnectBackoff)
+				So(dockerPlg.conn.retryAt, ShouldHappenAfter, time.Now().Add(minReconnectBackoff))
+
+				dockerPlg.conn.backoff = maxReconnectBackoff
+				dockerPlg.conn.retryAt = time.Now()
+				collect()
+				So(dockerPlg.conn.reconnectFailures, ShouldEqual, 2)
+				So(dockerPlg.conn.backoff, ShouldEqual, maxReconnectBackoff)
+			})
+
+			Convey("metrics of containers are collected again when docker daemon is reconnected", func() {
+				newClientErr = nil
+				dockerPlg.conn.retryAt = time.Now()
+				values := collect()
+				So(newClients, ShouldHaveLength, 1)
+				So(dockerPlg.client, ShouldEqual, newClients[0])
+				So(dockerPlg.rootDir, ShouldEqual, "/var/lib/docker")
+				mc.AssertCalled(t, "Close")
+				So(values["daemon/up"], ShouldEqual, 1)
+				So(values["daemon/reconnects"], ShouldEqual, 1)
+				So(values["daemon/images"], ShouldEqual, 12)
+				So(values, ShouldContainKey, mockDockerID+"/stats")
+			})
+		})
+
+		Convey("docker daemon which is not running at start is connected with backoff", func() {
+			startCfg := plugin.Config{"endpoint": "unix:///var/run/docker.sock", "procfs": "/proc", "exited_containers": true}
+			startMts := []plugin.Metric{daemonMt("up"), cpuMt}
+			for i := range startMts {
+				startMts[i].Config = startCfg
+			}
+			startPlg := newCollector()
+			// mount point of cgroup on host is not read from this host
+			startPlg.mounts[names["cpu_usage"]] = "/sys/fs/cgroup/cpuacct"
+			startCollect := func() map[string]interface{} {
+				metrics, err := startPlg.CollectMetrics(startMts)
+				So(err, ShouldBeNil)
+				values := map[string]interface{}{}
+				for _, metric := range metrics {
+					values[metric.Namespace.Strings()[2]+"/"+metric.Namespace.Strings()[3]] = metric.Data
+				}
+				return values
+			}
+
+			values := startCollect()
+			So(startPlg.client, ShouldBeNil)
+			So(startPlg.conn.down, ShouldBeTrue)
+			So(startPlg.conn.backoff, ShouldEqual, minReconnectBackoff)
+			So(startPlg.exited, ShouldNotBeNil)
+			So(values["daemon/up"], ShouldEqual, 0)
+			So(values, ShouldContainKey, "root/stats")
+
+			// docker client is not initialized again before the backoff elapses
+			newClientErr = nil
+			startCollect()
+			So(newClients, ShouldBeEmpty)
+
+			startPlg.conn.retryAt = time.Now()
+			values = startCollect()
+			So(newClients, ShouldHaveLength, 1)
+			So(startPlg.client, ShouldEqual, newClients[0])
+			So(values["daemon/up"], ShouldEqual, 1)
+			So(values, ShouldContainKey, mockDockerID+"/stats")
+			newClients[0].(*ClientMock).AssertCalled(t, "AddEventHandler", mock.Anything)
+		})
+
+		Convey("errors reported by docker API do not mark docker daemon as down", func() {
+			So(dockerPlg.disconnected(errors.New("No such container")), ShouldBeFalse)
+			So(dockerPlg.conn.down, ShouldBeFalse)
+			So(dockerPlg.connectionStats().Up, ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,192 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+)
+
+// endpointCollectorTTL says how long collector of docker endpoint is kept when none of its metrics is requested
+const endpointCollectorTTL = 30 * time.Minute
+
+// endpoints collects metrics of several docker endpoints (e.g. docker-in-docker build agents) in one plugin instance;
+// metrics are collected by collector of the endpoint given in their config, collectors are keyed by the whole config,
+// so a new collector is initialized when config of the endpoint changes
+type endpoints struct {
+	mutex        sync.Mutex
+	collectors   map[string]*endpointCollector
+	newCollector func() *collector
+	now          func() time.Time
+}
+
+// endpointCollector holds collector of docker endpoint, collections of the same endpoint are serialized
+type endpointCollector struct {
+	mutex     sync.Mutex
+	collector *collector
+	endpoint  string
+	used      time.Time
+}
+
+func newEndpoints(newCollector func() *collector) *endpoints {
+	return &endpoints{
+		collectors:   map[string]*endpointCollector{},
+		newCollector: newCollector,
+		now:          time.Now,
+	}
+}
+
+// CollectMetrics retrieves values of requested metrics from docker endpoints given in their config,
+// collected metrics are tagged with the endpoint; endpoints are collected independently, so an unreachable endpoint
+// only is logged and the error is returned only when none of the endpoints has been collected
+func (e *endpoints) CollectMetrics(mts []plugin.Metric) ([]plugin.Metric, error) {
+	keys := []string{}
+	groups := map[string][]plugin.Metric{}
+	for _, mt := range mts {
+		key := getConfigKey(mt.Config)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], mt)
+	}
+
+	var lastErr error
+	failed := 0
+	metrics := []plugin.Metric{}
+	for _, key := range keys {
+		ec := e.get(key, groups[key][0].Config)
+		collected, err := ec.collect(groups[key])
+		if err != nil {
+			log.WithFields(log.Fields{
+				"block":    "endpoints",
+				"function": "CollectMetrics",
+			}).Errorf("Unable to collect metrics of docker endpoint %s: %s", ec.endpoint, err)
+			lastErr = err
+			failed++
+			continue
+		}
+		metrics = append(metrics, collected...)
+	}
+	if failed > 0 && failed == len(keys) {
+		return nil, lastErr
+	}
+
+	return metrics, nil
+}
+
+// GetMetricTypes returns list of available metrics, they are the same for all docker endpoints
+func (e *endpoints) GetMetricTypes(cfg plugin.Config) ([]plugin.Metric, error) {
+	return e.newCollector().GetMetricTypes(cfg)
+}
+
+// GetConfigPolicy returns plugin config policy
+func (e *endpoints) GetConfigPolicy() (plugin.ConfigPolicy, error) {
+	return e.newCollector().GetConfigPolicy()
+}
+
+// get returns collector for the given config, collectors which have not been used for endpointCollectorTTL are dropped
+func (e *endpoints) get(key string, cfg plugin.Config) *endpointCollector {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := e.now()
+	for k, ec := range e.collectors {
+		if k != key && now.Sub(ec.used) >= endpointCollectorTTL {
+			delete(e.collectors, k)
+			go ec.close()
+		}
+	}
+
+	ec, ok := e.collectors[key]
+	if !ok {
+		endpoint, _ := cfg.GetString("endpoint")
+		ec = &endpointCollector{collector: e.newCollector(), endpoint: endpoint}
+		e.collectors[key] = ec
+	}
+	ec.used = now
+
+	return ec
+}
+
+// collect retrieves values of the metrics and tags them with the endpoint
+func (ec *endpointCollector) collect(mts []plugin.Metric) ([]plugin.Metric, error) {
+	ec.mutex.Lock()
+	defer ec.mutex.Unlock()
+
+	metrics, err := ec.collector.CollectMetrics(mts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range metrics {
+		tags := map[string]string{"endpoint": ec.endpoint}
+		for key, val := range metrics[i].Tags {
+			tags[key] = val
+		}
+		metrics[i].Tags = tags
+	}
+
+	return metrics, nil
+}
+
+// close releases docker client of the collector and stops sampling of its exited containers,
+// it waits for collection which is in progress
+func (ec *endpointCollector) close() {
+	ec.mutex.Lock()
+	defer ec.mutex.Unlock()
+
+	if ec.collector.exited != nil {
+		ec.collector.exited.stop()
+	}
+	if ec.collector.client == nil {
+		return
+	}
+	if err := ec.collector.client.Close(); err != nil {
+		log.WithFields(log.Fields{
+			"block":    "endpoints",
+			"function": "close",
+		}).Errorf("Unable to close docker client of endpoint %s: %s", ec.endpoint, err)
+	}
+}
+
+// getConfigKey returns representation of the config which is the same for equal configs
+func getConfigKey(cfg plugin.Config) string {
+	keys := make([]string, 0, len(cfg))
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, cfg[key]))
+	}
+
+	return strings.Join(pairs, ";")
+}
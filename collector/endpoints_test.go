@@ -0,0 +1,229 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+	. "github.com/intelsdi-x/snap-plugin-collector-docker/mocks"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+)
+
+func TestEndpoints(t *testing.T) {
+	Convey("Collect metrics of several docker endpoints", t, func() {
+		clients := []*ClientMock{}
+		closed := make(chan struct{}, 10)
+		e := newEndpoints(func() *collector {
+			mc := new(ClientMock)
+			mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+			mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+			mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+			mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			mc.On("Close").Return(nil).Run(func(mock.Arguments) { closed <- struct{}{} })
+			clients = append(clients, mc)
+
+			c := newCollector()
+			c.client = mc
+			return c
+		})
+		now := time.Unix(1500000000, 0)
+		e.now = func() time.Time { return now }
+		getters = MockGetters
+
+		cpuMt := func(endpoint string) plugin.Metric {
+			mt := plugin.Metric{
+				Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+					AddDynamicElement("docker_id", "an id of docker container").
+					AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+				Config: plugin.Config{"endpoint": endpoint, "procfs": "/proc"},
+			}
+			mt.Namespace[2].Value = mockDockerID
+			return mt
+		}
+
+		Convey("metrics are collected by collector of their endpoint and tagged with it", func() {
+			metrics, err := e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-1:2376"), cpuMt("tcp://agent-2:2376")})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(metrics[0].Tags["endpoint"], ShouldEqual, "tcp://agent-1:2376")
+			So(metrics[1].Tags["endpoint"], ShouldEqual, "tcp://agent-2:2376")
+			// labels of the container are kept
+			So(metrics[0].Tags["lkey1"], ShouldEqual, "lval1")
+			So(len(clients), ShouldEqual, 2)
+
+			// the same config is collected by the same collector
+			_, err = e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-1:2376")})
+			So(err, ShouldBeNil)
+			So(len(clients), ShouldEqual, 2)
+		})
+
+		Convey("new collector is initialized when config of the endpoint changes", func() {
+			_, err := e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-1:2376")})
+			So(err, ShouldBeNil)
+
+			changed := cpuMt("tcp://agent-1:2376")
+			changed.Config["tls_verify"] = false
+			_, err = e.CollectMetrics([]plugin.Metric{changed})
+			So(err, ShouldBeNil)
+			So(len(clients), ShouldEqual, 2)
+		})
+
+		Convey("collector which has not been used for a long time is closed", func() {
+			_, err := e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-1:2376")})
+			So(err, ShouldBeNil)
+
+			now = now.Add(endpointCollectorTTL)
+			_, err = e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-2:2376")})
+			So(err, ShouldBeNil)
+
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+			}
+			clients[0].AssertCalled(t, "Close")
+			clients[1].AssertNotCalled(t, "Close")
+			So(e.collectors, ShouldNotContainKey, getConfigKey(cpuMt("tcp://agent-1:2376").Config))
+		})
+
+		Convey("sampling of exited containers is stopped when collector is closed", func() {
+			c := newCollector()
+			c.exited = newExitedTracker(time.Hour, func(id string) (*container.ContainerData, error) {
+				return nil, errors.New("Container is not running")
+			})
+			c.exited.handle(mockDockerID, "start")
+			So(c.exited.sampling, ShouldNotBeEmpty)
+
+			(&endpointCollector{collector: c, endpoint: "tcp://agent-1:2376"}).close()
+			So(c.exited.sampling, ShouldBeEmpty)
+		})
+
+		Convey("unreachable endpoint does not fail collection of other endpoints", func() {
+			unreachable := func(endpoint string) {
+				mc := new(ClientMock)
+				mc.On("ListContainersAsMap").Return(nil, errors.New("Unable to list containers"))
+				c := newCollector()
+				c.client = mc
+				e.collectors[getConfigKey(cpuMt(endpoint).Config)] = &endpointCollector{collector: c, endpoint: endpoint, used: now}
+			}
+			unreachable("tcp://agent-2:2376")
+
+			metrics, err := e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-1:2376"), cpuMt("tcp://agent-2:2376")})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Tags["endpoint"], ShouldEqual, "tcp://agent-1:2376")
+
+			Convey("error is returned when none of the endpoints is collected", func() {
+				unreachable("tcp://agent-3:2376")
+				_, err := e.CollectMetrics([]plugin.Metric{cpuMt("tcp://agent-2:2376"), cpuMt("tcp://agent-3:2376")})
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("config key does not depend on order of config items", func() {
+			So(getConfigKey(plugin.Config{"endpoint": "tcp://agent-1:2376", "procfs": "/proc"}), ShouldEqual,
+				getConfigKey(plugin.Config{"procfs": "/proc", "endpoint": "tcp://agent-1:2376"}))
+			So(getConfigKey(plugin.Config{"endpoint": "tcp://agent-1:2376"}), ShouldNotEqual,
+				getConfigKey(plugin.Config{"endpoint": "tcp://agent-2:2376"}))
+		})
+	})
+}
+
+func TestRemoteEndpoint(t *testing.T) {
+	Convey("Collect metrics of docker endpoint which runs on another host", t, func() {
+		mc := new(ClientMock)
+		mc.On("GetDockerParams", mock.Anything).Return(map[string]string{"DockerRootDir": "/var/lib/docker", "Driver": "overlay2"}, nil)
+		mc.On("GetDockerVersion").Return([]int{1, 13})
+		mc.On("ListContainersAsMap").Return(mockListOfContainers, nil)
+		mc.On("InspectContainer", mock.Anything).Return(&docker.Container{}, nil)
+		mc.On("GetHostConfigExtras", mock.Anything).Return(&container.HostConfigExtras{}, nil)
+		defaultNewDockerClient := newDockerClient
+		newDockerClient = func(endpoint string, tlsOpts container.TLSOptions) (container.DockerClientInterface, error) {
+			return mc, nil
+		}
+		Reset(func() {
+			newDockerClient = defaultNewDockerClient
+		})
+		getters = MockGetters
+
+		cfg := plugin.Config{"endpoint": "tcp://agent-1:2376", "procfs": "/proc", "remote_endpoint": true, "exited_containers": true}
+		cpuMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("stats", "cgroups", "cpu_stats", "cpu_usage", "total"),
+			Config: cfg,
+		}
+		cpuMt.Namespace[2].Value = mockDockerID
+		specMt := plugin.Metric{
+			Namespace: plugin.NewNamespace(PLUGIN_VENDOR, PLUGIN_NAME).
+				AddDynamicElement("docker_id", "an id of docker container").
+				AddStaticElements("spec", "creation_time"),
+			Config: cfg,
+		}
+		specMt.Namespace[2].Value = mockDockerID
+		dockerPlg := newCollector()
+
+		Convey("only metrics read from docker API are collected", func() {
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{cpuMt, specMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 1)
+			So(metrics[0].Namespace.Strings()[3], ShouldEqual, "spec")
+			So(dockerPlg.remote, ShouldBeTrue)
+			// procfs and cgroups of this host are not read
+			mc.AssertNotCalled(t, "FindCgroupMountpoint", mock.Anything, mock.Anything)
+			// exited containers are sampled from procfs and cgroups, so they are not tracked
+			So(dockerPlg.exited, ShouldBeNil)
+		})
+
+		Convey("statistics of docker endpoint given as TCP address are collected unless it is set as remote", func() {
+			delete(cfg, "remote_endpoint")
+			cfg["endpoint"] = "tcp://127.0.0.1:2375"
+			mc.On("FindCgroupMountpoint", mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			mc.On("FindControllerMountpoint", mock.Anything, mock.Anything, mock.Anything).Return(mock.Anything, nil)
+			mc.On("AddEventHandler", mock.Anything).Return(nil)
+
+			metrics, err := dockerPlg.CollectMetrics([]plugin.Metric{cpuMt, specMt})
+			So(err, ShouldBeNil)
+			So(len(metrics), ShouldEqual, 2)
+			So(dockerPlg.remote, ShouldBeFalse)
+			So(dockerPlg.exited, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestNew(t *testing.T) {
+	Convey("New returns plugin collecting metrics of docker endpoints", t, func() {
+		plg := New()
+		So(plg, ShouldHaveSameTypeAs, &endpoints{})
+
+		metrics, err := plg.GetMetricTypes(plugin.Config{})
+		So(err, ShouldBeNil)
+		So(metrics, ShouldNotBeEmpty)
+	})
+}
@@ -0,0 +1,244 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+// defaultExitedSamplingInterval says how often containers which have not been collected yet are sampled
+const defaultExitedSamplingInterval = time.Second
+
+// exitedGroups holds query groups which are sampled for final snapshot of exited container
+var exitedGroups = []string{"cpu_usage", "usage", "statistics", "blkio_stats", "network"}
+
+// exitedTracker takes final snapshots of containers which start and exit between two collections;
+// docker removes cgroup and network namespace of the container before `die` event is published,
+// so such containers are sampled from their `start` event until the next listing of containers or until they exit
+type exitedTracker struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	sample   func(id string) (*container.ContainerData, error)
+	// containers which are sampled keyed by short id
+	sampling  map[string]sampling
+	snapshots map[string]*container.ContainerData
+}
+
+// sampling describes sampling of a container
+type sampling struct {
+	// true is sent when the container has exited
+	stop    chan bool
+	started time.Time
+}
+
+func newExitedTracker(interval time.Duration, sample func(id string) (*container.ContainerData, error)) *exitedTracker {
+	return &exitedTracker{
+		interval:  interval,
+		sample:    sample,
+		sampling:  map[string]sampling{},
+		snapshots: map[string]*container.ContainerData{},
+	}
+}
+
+// handle starts sampling of the container on its `start` event and takes final snapshot on its `die` event
+func (t *exitedTracker) handle(id, action string) {
+	shortID, err := container.GetShortID(id)
+	if err != nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch action {
+	case "start":
+		if _, ok := t.sampling[shortID]; ok {
+			return
+		}
+		stop := make(chan bool, 1)
+		t.sampling[shortID] = sampling{stop: stop, started: time.Now()}
+		go t.run(shortID, stop)
+	case "die":
+		if s, ok := t.sampling[shortID]; ok {
+			s.stop <- true
+			delete(t.sampling, shortID)
+		}
+	}
+}
+
+// run samples the container until sampling is stopped, the last successful sample is used when the container has exited
+// and its statistics cannot be read anymore
+func (t *exitedTracker) run(id string, stop chan bool) {
+	var last *container.ContainerData
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if data, err := t.sample(id); err == nil {
+			last = data
+		}
+
+		select {
+		case <-ticker.C:
+		case exited := <-stop:
+			if exited {
+				t.finish(id, last)
+			}
+			return
+		}
+	}
+}
+
+// finish stores final snapshot of the exited container, its specification (e.g. exit code) is taken from the final sample
+func (t *exitedTracker) finish(id string, last *container.ContainerData) {
+	final, err := t.sample(id)
+	if err != nil {
+		if last == nil {
+			log.WithFields(log.Fields{
+				"block":    "exitedTracker",
+				"function": "finish",
+			}).Debugf("No statistics of exited container %s are available: %s", id, err)
+			return
+		}
+		if final == nil {
+			final = last
+		} else {
+			final.Stats = last.Stats
+		}
+	}
+	final.Exited = true
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.snapshots[id] = final
+}
+
+// forget stops sampling of containers started before the listing of containers, they have been in the inventory since then,
+// so they are collected as usual (or they are not requested at all); containers listed as stopped are sampled until their `die` event
+func (t *exitedTracker) forget(listed time.Time, stopped func(id string) bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for id, s := range t.sampling {
+		if s.started.Before(listed) && !stopped(id) {
+			s.stop <- false
+			delete(t.sampling, id)
+		}
+	}
+}
+
+// stop stops sampling of all containers, e.g. when docker client of the collector is released
+func (t *exitedTracker) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for id, s := range t.sampling {
+		s.stop <- false
+		delete(t.sampling, id)
+	}
+}
+
+// take returns final snapshots of containers which have exited since the last call, each snapshot is returned only once
+func (t *exitedTracker) take() map[string]*container.ContainerData {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	snapshots := t.snapshots
+	t.snapshots = map[string]*container.ContainerData{}
+
+	return snapshots
+}
+
+// sampleContainer reads statistics of exitedGroups of the container, the returned data contains specification of the container
+// also when its statistics cannot be read (e.g. the container is not running anymore)
+func (c *collector) sampleContainer(id string) (*container.ContainerData, error) {
+	c.clientMutex.RLock()
+	client, rootDir := c.client, c.rootDir
+	c.clientMutex.RUnlock()
+
+	cont, err := client.InspectContainer(id)
+	if err != nil {
+		return nil, err
+	}
+	// state of the container changes when it exits, so cached inspect info is not used for containers which are not running
+	if !cont.State.Running || cont.State.Pid <= 0 {
+		if cont, err = client.ReinspectContainer(id); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	data := &container.ContainerData{
+		ID: cont.ID,
+		Specification: container.Specification{
+			Status:   cont.State.StateString(),
+			Created:  cont.Created.Format("2006-01-02T15:04:05Z07:00"),
+			Networks: getNetworksSpec(cont),
+			State:    getStateSpec(cont, now),
+			Health:   getHealthSpec(cont),
+		},
+		Stats: container.NewStatistics(),
+	}
+	if cont.Config != nil {
+		data.Specification.Image = cont.Config.Image
+		data.Specification.Labels = cont.Config.Labels
+	}
+	setConfigSpec(&data.Specification, cont, getHostConfigExtras(client, id))
+
+	if !cont.State.Running || cont.State.Pid <= 0 {
+		return data, fmt.Errorf("Container %s is not running", id)
+	}
+
+	procfs := c.conf["procfs"]
+	opts := container.GetStatOpt{
+		"procfs":        procfs,
+		"root_dir":      rootDir,
+		"is_host":       false,
+		"pid":           cont.State.Pid,
+		"container_id":  cont.ID,
+		"container_drv": cont.Driver,
+	}
+
+	for _, group := range exitedGroups {
+		if isCgroupGroup(group) {
+			cpath, err := client.FindControllerMountpoint(names[group], strconv.Itoa(cont.State.Pid), procfs)
+			if err != nil {
+				return data, err
+			}
+			opts["cgroup_path"] = cpath
+		}
+
+		if err := getters[group].GetStats(data.Stats, opts); err != nil {
+			return data, err
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,182 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+const mockExitedID = "c48e074e044e1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b"
+
+// fakeSampler returns samples with total cpu usage growing with each sample until the container exits
+type fakeSampler struct {
+	mutex   sync.Mutex
+	exited  bool
+	failing bool
+	samples uint64
+	sampled chan struct{}
+}
+
+func (f *fakeSampler) sample(id string) (*container.ContainerData, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	defer func() {
+		select {
+		case f.sampled <- struct{}{}:
+		default:
+		}
+	}()
+
+	data := &container.ContainerData{ID: id, Stats: container.NewStatistics()}
+	if f.exited {
+		data.Specification.Status = "exited"
+		data.Specification.State.ExitCode = 3
+		return data, errors.New("Container is not running")
+	}
+	if f.failing {
+		return nil, errors.New("No such container")
+	}
+
+	f.samples++
+	data.Specification.Status = "running"
+	data.Stats.Cgroups.CpuStats.CpuUsage.Total = f.samples
+	return data, nil
+}
+
+func (f *fakeSampler) exit() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.exited = true
+}
+
+// waitForSnapshots returns snapshots taken by the tracker, it waits for them at most one second
+func waitForSnapshots(tracker *exitedTracker) map[string]*container.ContainerData {
+	snapshots := map[string]*container.ContainerData{}
+	for i := 0; i < 100 && len(snapshots) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		snapshots = tracker.take()
+	}
+	return snapshots
+}
+
+func TestExitedTracker(t *testing.T) {
+	Convey("Take final snapshots of exited containers", t, func() {
+		sampler := &fakeSampler{sampled: make(chan struct{}, 1)}
+		tracker := newExitedTracker(5*time.Millisecond, sampler.sample)
+
+		Convey("snapshot of exited container contains the last statistics and the final specification", func() {
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			<-sampler.sampled
+			sampler.exit()
+			tracker.handle(mockExitedID, "die")
+
+			snapshots := waitForSnapshots(tracker)
+			So(snapshots, ShouldContainKey, "c48e074e044e")
+			snapshot := snapshots["c48e074e044e"]
+			So(snapshot.Exited, ShouldBeTrue)
+			So(snapshot.Stats.Cgroups.CpuStats.CpuUsage.Total, ShouldBeGreaterThanOrEqualTo, 2)
+			So(snapshot.Specification.Status, ShouldEqual, "exited")
+			So(snapshot.Specification.State.ExitCode, ShouldEqual, 3)
+
+			// snapshot is returned only once
+			So(tracker.take(), ShouldBeEmpty)
+		})
+
+		Convey("snapshot is not taken for container which has been collected as usual", func() {
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			tracker.forget(time.Now(), func(id string) bool { return false })
+			So(tracker.sampling, ShouldBeEmpty)
+			sampler.exit()
+			tracker.handle(mockExitedID, "die")
+
+			time.Sleep(20 * time.Millisecond)
+			So(tracker.take(), ShouldBeEmpty)
+		})
+
+		Convey("container is sampled until containers are listed after its start", func() {
+			listed := time.Now()
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			tracker.forget(listed, func(id string) bool { return false })
+			So(tracker.sampling, ShouldContainKey, "c48e074e044e")
+
+			// containers which are not listed at all (e.g. filtered out) are not sampled anymore either
+			tracker.forget(time.Now(), func(id string) bool { return false })
+			So(tracker.sampling, ShouldBeEmpty)
+		})
+
+		Convey("container listed as stopped is sampled until its die event", func() {
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			<-sampler.sampled
+			tracker.forget(time.Now(), func(id string) bool { return id == "c48e074e044e" })
+			So(tracker.sampling, ShouldContainKey, "c48e074e044e")
+			sampler.exit()
+			tracker.handle(mockExitedID, "die")
+
+			So(waitForSnapshots(tracker), ShouldContainKey, "c48e074e044e")
+		})
+
+		Convey("snapshot is not taken when statistics of container have never been read", func() {
+			sampler.failing = true
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			tracker.handle(mockExitedID, "die")
+
+			time.Sleep(20 * time.Millisecond)
+			So(tracker.take(), ShouldBeEmpty)
+		})
+
+		Convey("sampling of all containers is stopped when the tracker is stopped", func() {
+			tracker.handle(mockExitedID, "start")
+			<-sampler.sampled
+			tracker.stop()
+			So(tracker.sampling, ShouldBeEmpty)
+
+			// the container is not sampled anymore
+			time.Sleep(20 * time.Millisecond)
+			select {
+			case <-sampler.sampled:
+			default:
+			}
+			time.Sleep(20 * time.Millisecond)
+			So(sampler.sampled, ShouldBeEmpty)
+		})
+
+		Convey("events of containers which are not sampled are ignored", func() {
+			tracker.handle(mockExitedID, "die")
+			tracker.handle("invalid", "start")
+			So(tracker.sampling, ShouldBeEmpty)
+		})
+	})
+}
@@ -23,8 +23,16 @@ package collector
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
@@ -56,10 +64,73 @@ var definedDynamicElements = map[string]dynamicElement{
 	"io_time_recursive":          {"device_name", "a name of block device"},
 	"sectors_recursive":          {"device_name", "a name of block device"},
 	"hugetlb_stats":              {"size", "hugetlb page size"},
+	"listen_backlog":             {"port", "a local port of listening socket"},
+	"per_port":                   {"port", "a port number and protocol, e.g. 80_tcp"},
+	"networks":                   {"network_name", "a name of docker network"},
+	"ulimits":                    {"ulimit_name", "a name of ulimit, e.g. nofile"},
+	"qdisc":                      {"handle", "a handle of qdisc or class, e.g. 1:10"},
+	"image":                      {"image_name", "a name of docker image"},
+	"volume":                     {"volume_name", "a name of docker volume"},
+	"peers":                      {"peer", "a remote endpoint (address or network, optionally with port) or 'other' for aggregate"},
+	"icmp":                       {"counter", "a name of ICMP counter"},
+	"icmp6":                      {"counter", "a name of ICMP6 counter"},
+	"icmp_msg":                   {"counter", "a name of ICMP message type counter"},
+	"ip":                         {"counter", "a name of IP counter"},
+	"ip6":                        {"counter", "a name of IP6 counter"},
+	"ip_ext":                     {"counter", "a name of extended IP counter"},
+	"tcp":                        {"counter", "a name of TCP counter"},
+	"tcp_ext":                    {"counter", "a name of extended TCP counter"},
+	"udp":                        {"counter", "a name of UDP counter"},
+	"udp6":                       {"counter", "a name of UDP6 counter"},
+	"udp_lite":                   {"counter", "a name of UDP-Lite counter"},
+	"udp_lite6":                  {"counter", "a name of UDP-Lite6 counter"},
 }
 
-func initClient(c *collector, endpoint string) error {
-	dc, err := container.NewDockerClient(endpoint)
+// nonCgroupGroups holds query groups which statistics are not read from cgroup controllers
+var nonCgroupGroups = map[string]struct{}{
+	"network":    {},
+	"tcp":        {},
+	"tcp6":       {},
+	"udp":        {},
+	"udp6":       {},
+	"unix":       {},
+	"raw":        {},
+	"raw6":       {},
+	"snmp":       {},
+	"ports":      {},
+	"peers":      {},
+	"sockstat":   {},
+	"conntrack":  {},
+	"qdisc":      {},
+	"filesystem": {},
+}
+
+// peersConfig holds configuration of aggregating connections by remote endpoint
+type peersConfig struct {
+	topN       int  // the number of peers with the most connections reported separately, 0 means no limit
+	ipv4Prefix int  // length of prefix used to group remote IPv4 addresses into networks
+	ipv6Prefix int  // length of prefix used to group remote IPv6 addresses into networks
+	byPort     bool // whether remote port distinguishes peers
+}
+
+// defaultPeersConfig reports separately 10 remote addresses with the most connections
+var defaultPeersConfig = peersConfig{
+	topN:       10,
+	ipv4Prefix: 32,
+	ipv6Prefix: 128,
+	byPort:     false,
+}
+
+// newDockerClient returns client of docker endpoint, TLS is used when any of certificates is given
+var newDockerClient = func(endpoint string, tlsOpts container.TLSOptions) (container.DockerClientInterface, error) {
+	if tlsOpts.Enabled() {
+		return container.NewDockerTLSClient(endpoint, tlsOpts)
+	}
+	return container.NewDockerClient(endpoint)
+}
+
+func initClient(c *collector, endpoint string, tlsOpts container.TLSOptions) error {
+	dc, err := newDockerClient(endpoint, tlsOpts)
 	if err != nil {
 		return err
 	}
@@ -69,9 +140,13 @@ func initClient(c *collector, endpoint string) error {
 		return err
 	}
 
+	// client is replaced when docker daemon is reconnected, while it can be used by sampling of exited containers
+	c.clientMutex.Lock()
 	c.rootDir = params["DockerRootDir"]
 	c.driver = params["Driver"]
+	c.dockerVersion = dc.GetDockerVersion()
 	c.client = dc
+	c.clientMutex.Unlock()
 
 	log.WithFields(log.Fields{
 		"block": "initClient",
@@ -125,10 +200,15 @@ func (creator *nsCreator) createMetricNamespace(ns plugin.Namespace, metricName
 }
 
 func getQueryGroup(ns []string) (string, error) {
-	if ns[0] == "spec" {
+	if ns[0] == "spec" || ns[0] == "networks" || ns[0] == "events" || ns[0] == "disk_usage" {
 		return ns[0], nil
 	}
 
+	// statistics of qdiscs are exposed under network interface, but they are read by separate getter
+	if len(ns) > 3 && ns[1] == "network" && ns[3] == "qdisc" {
+		return ns[3], nil
+	}
+
 	for _, ne := range ns {
 		if _, exists := getters[ne]; exists {
 			return ne, nil
@@ -137,6 +217,412 @@ func getQueryGroup(ns []string) (string, error) {
 	return "", fmt.Errorf("Cannot identify query group for given namespace %s", strings.Join(ns, "/"))
 }
 
+// isCgroupGroup returns true if statistics of a given query group are read from cgroup controller
+func isCgroupGroup(group string) bool {
+	_, exists := nonCgroupGroups[group]
+	return !exists
+}
+
+// getPublishedPorts returns ports (in format `<port>_<proto>`, e.g. 80_tcp) which are published by the container,
+// both currently bound ports and ports requested in host configuration are taken into account
+func getPublishedPorts(cont *docker.Container) []string {
+	ports := []string{}
+	seen := map[string]struct{}{}
+	bindings := []map[docker.Port][]docker.PortBinding{}
+
+	if cont.NetworkSettings != nil {
+		bindings = append(bindings, cont.NetworkSettings.Ports)
+	}
+	if cont.HostConfig != nil {
+		bindings = append(bindings, cont.HostConfig.PortBindings)
+	}
+
+	for _, portBindings := range bindings {
+		for port, binding := range portBindings {
+			// exposed port without binding to the host is not published
+			if len(binding) == 0 {
+				continue
+			}
+			name := port.Port() + "_" + port.Proto()
+			if _, exists := seen[name]; !exists {
+				seen[name] = struct{}{}
+				ports = append(ports, name)
+			}
+		}
+	}
+
+	return ports
+}
+
+// getNetworksSpec returns identity of the container (IP address, MAC address, gateway) in each of attached docker networks
+func getNetworksSpec(cont *docker.Container) map[string]container.NetworkSpec {
+	networks := map[string]container.NetworkSpec{}
+	if cont.NetworkSettings == nil {
+		return networks
+	}
+
+	for name, network := range cont.NetworkSettings.Networks {
+		networks[name] = container.NetworkSpec{
+			IPAddress:  network.IPAddress,
+			MacAddress: network.MacAddress,
+			Gateway:    network.Gateway,
+		}
+	}
+
+	return networks
+}
+
+// getHostConfigExtras returns settings of the container which are not decoded by docker client (e.g. CPU limit given as the number of CPUs),
+// nil is returned when they are not available
+func getHostConfigExtras(client container.DockerClientInterface, id string) *container.HostConfigExtras {
+	extras, err := client.GetHostConfigExtras(id)
+	if err != nil {
+		// only log error, the rest of configuration of the container is still available
+		log.WithFields(log.Fields{
+			"block":    "utils",
+			"function": "getHostConfigExtras",
+		}).Error(err)
+		return nil
+	}
+	return extras
+}
+
+// setConfigSpec sets configuration of the container (command, restart policy, resource limits, etc.) in its specification,
+// extras are settings of the container which are not decoded by docker client, they are omitted when nil
+func setConfigSpec(spec *container.Specification, cont *docker.Container, extras *container.HostConfigExtras) {
+	spec.Name = strings.TrimPrefix(cont.Name, "/")
+	if cont.Image != "" {
+		spec.ImageID = cont.Image
+	}
+	spec.Mounts = uint64(len(cont.Mounts))
+	spec.PublishedPorts = uint64(len(getPublishedPorts(cont)))
+
+	if cont.Config != nil {
+		spec.Command = strings.Join(cont.Config.Cmd, " ")
+		spec.Entrypoint = strings.Join(cont.Config.Entrypoint, " ")
+	}
+
+	if cont.HostConfig == nil {
+		return
+	}
+	hc := cont.HostConfig
+
+	spec.RestartPolicy = hc.RestartPolicy.Name
+	spec.RestartMaxRetries = uint64(hc.RestartPolicy.MaximumRetryCount)
+	spec.LogDriver = hc.LogConfig.Type
+	spec.Limits = container.LimitsSpec{
+		Memory:      hc.Memory,
+		MemorySwap:  hc.MemorySwap,
+		CpuQuota:    hc.CPUQuota,
+		CpusetCpus:  hc.CPUSetCPUs,
+		PidsLimit:   hc.PidsLimit,
+		BlkioWeight: hc.BlkioWeight,
+		Ulimits:     map[string]container.UlimitSpec{},
+	}
+	for _, ulimit := range hc.Ulimits {
+		spec.Limits.Ulimits[ulimit.Name] = container.UlimitSpec{Soft: ulimit.Soft, Hard: ulimit.Hard}
+	}
+
+	if extras != nil {
+		spec.Runtime = extras.Runtime
+		spec.Limits.NanoCpus = extras.NanoCpus
+	}
+}
+
+// containerStateCodes maps state of container (as presented by docker) to its numeric code
+var containerStateCodes = map[string]uint64{
+	"created":    0,
+	"running":    1,
+	"paused":     2,
+	"restarting": 3,
+	"removing":   4,
+	"exited":     5,
+	"dead":       6,
+}
+
+// getStatusSpec returns status of the container in the same form as presented by docker ps (e.g. "Up 4 minutes (healthy)"),
+// it is rendered from inspect info relatively to the given time, so it does not go stale when containers are not listed
+func getStatusSpec(cont *docker.Container, now time.Time) string {
+	state := cont.State
+	if state.Running {
+		if state.Paused {
+			return fmt.Sprintf("Up %s (Paused)", units.HumanDuration(now.Sub(state.StartedAt)))
+		}
+		if state.Restarting {
+			return fmt.Sprintf("Restarting (%d) %s ago", state.ExitCode, units.HumanDuration(now.Sub(state.FinishedAt)))
+		}
+		switch state.Health.Status {
+		case "":
+		case "starting":
+			return fmt.Sprintf("Up %s (health: starting)", units.HumanDuration(now.Sub(state.StartedAt)))
+		default:
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(now.Sub(state.StartedAt)), state.Health.Status)
+		}
+		return fmt.Sprintf("Up %s", units.HumanDuration(now.Sub(state.StartedAt)))
+	}
+
+	if state.RemovalInProgress {
+		return "Removal In Progress"
+	}
+	if state.Dead {
+		return "Dead"
+	}
+	if state.StartedAt.IsZero() {
+		return "Created"
+	}
+	if state.FinishedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("Exited (%d) %s ago", state.ExitCode, units.HumanDuration(now.Sub(state.FinishedAt)))
+}
+
+// getStateSpec returns lifecycle state of the container in numeric form, uptime is calculated relatively to the given time
+func getStateSpec(cont *docker.Container, now time.Time) container.StateSpec {
+	state := cont.State
+	spec := container.StateSpec{
+		Code:         containerStateCodes[state.StateString()],
+		Running:      boolToUint(state.Running),
+		Paused:       boolToUint(state.Paused),
+		Restarting:   boolToUint(state.Restarting),
+		OOMKilled:    boolToUint(state.OOMKilled),
+		Dead:         boolToUint(state.Dead),
+		ExitCode:     int64(state.ExitCode),
+		RestartCount: uint64(cont.RestartCount),
+	}
+
+	if state.RemovalInProgress {
+		spec.Code = containerStateCodes["removing"]
+	}
+
+	if state.Running && !state.StartedAt.IsZero() && now.After(state.StartedAt) {
+		spec.Uptime = uint64(now.Sub(state.StartedAt).Seconds())
+	}
+
+	if !state.FinishedAt.IsZero() {
+		spec.FinishedAt = state.FinishedAt.Unix()
+	}
+
+	return spec
+}
+
+// healthStatusCodes maps health status of container (as presented by docker) to its numeric code
+var healthStatusCodes = map[string]uint64{
+	"none":      0,
+	"starting":  1,
+	"healthy":   2,
+	"unhealthy": 3,
+}
+
+// getHealthSpec returns status of the container's healthcheck and summary of probes retained in its log
+func getHealthSpec(cont *docker.Container) container.HealthSpec {
+	health := cont.State.Health
+	spec := container.HealthSpec{
+		Status:        healthStatusCodes[health.Status],
+		FailingStreak: uint64(health.FailingStreak),
+		Probes:        uint64(len(health.Log)),
+	}
+
+	for _, probe := range health.Log {
+		if probe.ExitCode != 0 {
+			spec.FailedProbes++
+		}
+	}
+
+	// the most recent probe is the last one in the log
+	if len(health.Log) > 0 {
+		last := health.Log[len(health.Log)-1]
+		spec.LastExitCode = int64(last.ExitCode)
+		if last.End.After(last.Start) {
+			spec.LastDuration = last.End.Sub(last.Start).Seconds()
+		}
+	}
+
+	return spec
+}
+
+func boolToUint(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// getNetworksTags returns tags describing docker networks to which the container is attached,
+// names of networks and corresponding IP and MAC addresses are comma-separated
+func getNetworksTags(networks map[string]container.NetworkSpec) map[string]string {
+	names := []string{}
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ipAddresses := []string{}
+	macAddresses := []string{}
+	for _, name := range names {
+		ipAddresses = append(ipAddresses, networks[name].IPAddress)
+		macAddresses = append(macAddresses, networks[name].MacAddress)
+	}
+
+	return map[string]string{
+		"networks":      strings.Join(names, ","),
+		"ip_addresses":  strings.Join(ipAddresses, ","),
+		"mac_addresses": strings.Join(macAddresses, ","),
+	}
+}
+
+// getNetworksInventory returns information about docker networks and utilization of their IPv4 address pools
+func getNetworksInventory(networks []docker.Network) map[string]container.NetworkInventory {
+	inventory := map[string]container.NetworkInventory{}
+
+	for _, network := range networks {
+		stats := container.NetworkInventory{
+			Driver:     network.Driver,
+			Scope:      network.Scope,
+			Containers: uint64(len(network.Containers)),
+		}
+
+		pools := []*net.IPNet{}
+		var available uint64
+		for _, cfg := range network.IPAM.Config {
+			pool, size, usable, ok := getIPv4Pool(cfg)
+			if !ok {
+				continue
+			}
+			pools = append(pools, pool)
+			stats.SubnetSize += size
+			available += usable
+		}
+
+		// addresses might be given with prefix length (e.g. addresses of containers)
+		allocated := map[string]struct{}{}
+		allocate := func(address string) {
+			ip := net.ParseIP(strings.Split(address, "/")[0])
+			if ip == nil || ip.To4() == nil {
+				return
+			}
+			for _, pool := range pools {
+				if pool.Contains(ip) {
+					allocated[ip.String()] = struct{}{}
+					return
+				}
+			}
+		}
+
+		for _, cfg := range network.IPAM.Config {
+			allocate(cfg.Gateway)
+			for _, address := range cfg.AuxAddress {
+				allocate(address)
+			}
+		}
+		for _, endpoint := range network.Containers {
+			allocate(endpoint.IPv4Address)
+		}
+
+		stats.Allocated = uint64(len(allocated))
+		if available > 0 {
+			stats.Utilization = float64(stats.Allocated) / float64(available)
+		}
+
+		inventory[network.Name] = stats
+	}
+
+	return inventory
+}
+
+// getIPv4Pool returns the range from which docker IPAM allocates IPv4 addresses in the given subnet (IP range if it is
+// configured, otherwise the whole subnet), the number of its addresses and the number of addresses available for allocation
+// (network and broadcast addresses of subnet are excluded); false is returned when subnet is not IPv4
+func getIPv4Pool(cfg docker.IPAMConfig) (*net.IPNet, uint64, uint64, bool) {
+	_, subnet, err := net.ParseCIDR(cfg.Subnet)
+	if err != nil || subnet.IP.To4() == nil {
+		return nil, 0, 0, false
+	}
+
+	pool := subnet
+	if cfg.IPRange != "" {
+		if _, ipRange, err := net.ParseCIDR(cfg.IPRange); err == nil && ipRange.IP.To4() != nil {
+			pool = ipRange
+		}
+	}
+
+	ones, bits := pool.Mask.Size()
+	size := uint64(1) << uint(bits-ones)
+	usable := size
+
+	// subnets /31 and /32 have neither network nor broadcast address
+	if ones, _ := subnet.Mask.Size(); ones < 31 {
+		broadcast := make(net.IP, len(subnet.IP))
+		for i := range subnet.IP {
+			broadcast[i] = subnet.IP[i] | ^subnet.Mask[i]
+		}
+		for _, ip := range []net.IP{subnet.IP, broadcast} {
+			if pool.Contains(ip) {
+				usable--
+			}
+		}
+	}
+
+	return pool, size, usable, true
+}
+
+// isContainerProcess returns true if the process with the given pid has been started together with the container,
+// cached pid of a restarted container may refer to a process which does not exist anymore or which has reused the pid,
+// it is only a hint to inspect the container again as start time of the container is not exact;
+// cgroup of the process cannot be used for that as it is not visible in private cgroup namespace (cgroup v2)
+func isContainerProcess(procfs string, pid int, startedAt time.Time) bool {
+	started, err := getProcessStartTime(procfs, pid)
+	if err != nil {
+		return false
+	}
+
+	diff := started.Sub(startedAt)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= processStartTolerance
+}
+
+// getProcessStartTime returns start time of the process computed from boot time of the host (/proc/stat)
+// and start time of the process in clock ticks after boot (/proc/<pid>/stat)
+func getProcessStartTime(procfs string, pid int) (time.Time, error) {
+	stat, err := ioutil.ReadFile(filepath.Join(procfs, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// command of the process may contain spaces, so fields are counted after the closing parenthesis of command
+	end := strings.LastIndex(string(stat), ")")
+	if end < 0 {
+		return time.Time{}, fmt.Errorf("Invalid format of stat of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// starttime is the 22nd field, i.e. the 20th after command
+	if len(fields) < 20 {
+		return time.Time{}, fmt.Errorf("Invalid format of stat of process %d", pid)
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	hostStat, err := ioutil.ReadFile(filepath.Join(procfs, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(hostStat), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "btime" {
+			btime, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(btime, 0).Add(time.Duration(ticks) * (time.Second / clockTicks)), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Boot time not found in %s", filepath.Join(procfs, "stat"))
+}
+
 func appendIfMissing(collectGroup map[string]map[string]struct{}, rid string, query string) {
 	group, exists := collectGroup[rid]
 	if !exists {
@@ -148,6 +634,117 @@ func appendIfMissing(collectGroup map[string]map[string]struct{}, rid string, qu
 	}
 }
 
+// getPeersConfig returns configuration of aggregating connections by remote endpoint,
+// defaults are used for values which are not set
+func getPeersConfig(cfg plugin.Config) peersConfig {
+	conf := defaultPeersConfig
+
+	if topN, err := cfg.GetInt("peers_top_n"); err == nil {
+		conf.topN = int(topN)
+	}
+	if ipv4Prefix, err := cfg.GetInt("peers_ipv4_prefix"); err == nil {
+		conf.ipv4Prefix = int(ipv4Prefix)
+	}
+	if ipv6Prefix, err := cfg.GetInt("peers_ipv6_prefix"); err == nil {
+		conf.ipv6Prefix = int(ipv6Prefix)
+	}
+	if byPort, err := cfg.GetBool("peers_by_port"); err == nil {
+		conf.byPort = byPort
+	}
+
+	return conf
+}
+
+const (
+	// defaultSizeInterval says how often sizes of containers are computed when it is enabled
+	defaultSizeInterval = 10 * time.Minute
+	// defaultResyncInterval says how often all containers are listed when they are tracked by docker events
+	defaultResyncInterval = 5 * time.Minute
+	// defaultDiskUsageInterval says how often disk usage of docker objects (images, volumes, build cache) is computed
+	defaultDiskUsageInterval = 10 * time.Minute
+	// processStartTolerance is the maximal difference between start time of the process of container and start time
+	// of the container reported by docker; docker sets start time after the process is forked and prestart hooks
+	// (e.g. networking or GPU setup) have finished, which can take long time on loaded host
+	processStartTolerance = time.Minute
+	// clockTicks is the unit of times in procfs (USER_HZ), it is the same on all architectures
+	clockTicks = 100
+)
+
+// splitMetricsByNs returns the other metrics and metrics under the given namespace which is not related to any container
+// (e.g. metrics of docker daemon)
+func splitMetricsByNs(mts []plugin.Metric, ns string) ([]plugin.Metric, []plugin.Metric) {
+	otherMts := []plugin.Metric{}
+	nsMts := []plugin.Metric{}
+	for _, mt := range mts {
+		if len(mt.Namespace) > lengthOfNsPrefix && mt.Namespace[2].Value == ns {
+			nsMts = append(nsMts, mt)
+			continue
+		}
+		otherMts = append(otherMts, mt)
+	}
+	return otherMts, nsMts
+}
+
+// getListOptions returns options of listing containers, statuses are given in config as comma-separated list
+func getListOptions(cfg plugin.Config) container.ListOptions {
+	opts := container.ListOptions{}
+
+	if all, err := cfg.GetBool("all_containers"); err == nil {
+		opts.All = all
+	}
+	if statuses, err := cfg.GetString("container_statuses"); err == nil {
+		for _, status := range strings.Split(statuses, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				opts.Statuses = append(opts.Statuses, status)
+			}
+		}
+	}
+	if size, err := cfg.GetBool("container_size"); err == nil && size {
+		opts.SizeInterval = getInterval(cfg, "container_size_interval", defaultSizeInterval)
+	}
+	if events, err := cfg.GetBool("container_events"); err == nil && events {
+		opts.ResyncInterval = getInterval(cfg, "container_resync_interval", defaultResyncInterval)
+	}
+
+	return opts
+}
+
+// getInterval returns positive duration given in config by the key, the default is used when it is missing or invalid
+func getInterval(cfg plugin.Config, key string, defaultInterval time.Duration) time.Duration {
+	interval, err := cfg.GetString(key)
+	if err != nil {
+		return defaultInterval
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		log.WithFields(log.Fields{
+			"block": "getInterval",
+		}).Warnf("Invalid interval %q given by %s, default %s is used", interval, key, defaultInterval)
+		return defaultInterval
+	}
+
+	return d
+}
+
+// getTLSConfig returns paths of certificates used for docker endpoint secured by TLS, certificates are validated when any of them is given;
+// certificate of docker endpoint is verified unless it is disabled in config
+func getTLSConfig(cfg plugin.Config) (container.TLSOptions, error) {
+	opts := container.TLSOptions{Verify: true}
+	opts.CA, _ = cfg.GetString("tls_ca")
+	opts.Cert, _ = cfg.GetString("tls_cert")
+	opts.Key, _ = cfg.GetString("tls_key")
+	if verify, err := cfg.GetBool("tls_verify"); err == nil {
+		opts.Verify = verify
+	}
+
+	if !opts.Enabled() {
+		return opts, nil
+	}
+
+	return opts, opts.Validate()
+}
+
 func getDockerConfig(cfg plugin.Config) (map[string]string, error) {
 	config := make(map[string]string)
 	values := []string{"endpoint", "procfs"}
@@ -23,16 +23,23 @@ package container
 
 import (
 	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fsouza/go-dockerclient"
-	"github.com/intelsdi-x/snap-plugin-collector-docker/config"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -42,19 +49,69 @@ const (
 
 // DockerClientInterface provides methods i.a. for interaction with the docker API.
 type DockerClientInterface interface {
-	ListContainersAsMap() (map[string]*ContainerData, error)
+	ListContainersAsMap(ListOptions) (map[string]*ContainerData, error)
 	InspectContainer(string) (*docker.Container, error)
+	ReinspectContainer(string) (*docker.Container, error)
+	GetHostConfigExtras(string) (*HostConfigExtras, error)
 	FindCgroupMountpoint(string, string) (string, error)
 	FindControllerMountpoint(string, string, string) (string, error)
 	GetDockerParams(...string) (map[string]string, error)
+	GetDockerVersion() []int
+	ListNetworks() ([]docker.Network, error)
+	GetEventsStats() (map[string]EventsStats, error)
+	AddEventHandler(func(id, action string)) error
+	GetDaemonInfo() (*DaemonInfo, error)
+	GetDiskUsage(time.Duration) (*DiskUsage, error)
+	GetImagesInventory() (map[string]ImageInventory, error)
+	Close() error
 }
 
 // DockerClient holds go-dockerclient instance ready for communication with the server endpoint `unix:///var/run/docker.sock`,
-// cache instance which is used to store output from docker container inspect (to avoid execute inspect request multiply times, it is called only once per container)
+// cache instance which is used to store output from docker container inspect (to avoid execute inspect request multiply times, it is called
+// only once per container unless status of the container changes or the inspect info expires)
 type DockerClient struct {
 	cl           *docker.Client
-	inspectCache map[string]*docker.Container
-	inspectMutex sync.Mutex
+	endpoint     string
+	inspectCache *inspectCache
+	// major and minor version of docker engine, each endpoint can run other version
+	version []int
+	// cache of sizes of containers which are computed by docker only on demand as it is expensive
+	sizes           map[string]containerSize
+	sizesUpdated    time.Time
+	sizesRefreshing bool
+	sizesMutex      sync.Mutex
+	// containers tracked by docker events, used when resync interval is set in options of listing
+	inventory containerInventory
+	// statistics of docker events, monitoring of events is shared with the inventory
+	events      *eventsRecorder
+	handlers    []func(id, action string)
+	subscribed  bool
+	eventsMutex sync.Mutex
+	// closed is closed when the client is not used anymore, it stops monitoring of docker events
+	closed chan struct{}
+	// cache of disk usage of docker objects which is computed by docker only on demand as it is expensive
+	diskUsage diskUsageCache
+	// cache of images used to resolve images of listed containers
+	images imagesCache
+}
+
+// containerSize holds size of files created or changed in container and total size of all files in container
+type containerSize struct {
+	rw     int64
+	rootFs int64
+}
+
+// ListOptions describes which containers are listed, by default only running containers are listed
+type ListOptions struct {
+	// All includes containers which are not running (e.g. exited)
+	All bool
+	// Statuses limits listed containers to the given statuses (e.g. running, exited, dead)
+	Statuses []string
+	// SizeInterval says how often sizes of containers are computed, 0 disables computing sizes
+	SizeInterval time.Duration
+	// ResyncInterval enables tracking of containers by docker events, all containers are listed only once per this interval,
+	// 0 means containers are listed on each call
+	ResyncInterval time.Duration
 }
 
 type deviceInfo struct {
@@ -63,6 +120,70 @@ type deviceInfo struct {
 	minor  string
 }
 
+// TLSOptions holds paths of certificates used for communication with the server endpoint secured by TLS, e.g. `tcp://<host>:2376`
+type TLSOptions struct {
+	// CA is the certificate of authority which signed the certificate of the server, system roots are used when it is empty
+	CA string
+	// Cert and Key are the client certificate and its private key, both are empty when the server does not authenticate clients
+	Cert string
+	Key  string
+	// Verify enables verification of the certificate of the server
+	Verify bool
+}
+
+// Enabled returns true when any of certificates is given
+func (opts TLSOptions) Enabled() bool {
+	return opts.CA != "" || opts.Cert != "" || opts.Key != ""
+}
+
+// Validate returns an error when the given certificates cannot be loaded
+func (opts TLSOptions) Validate() error {
+	if (opts.Cert == "") != (opts.Key == "") {
+		return errors.New("Both client certificate and its key have to be given for TLS")
+	}
+
+	if opts.Cert != "" {
+		if _, err := tls.LoadX509KeyPair(opts.Cert, opts.Key); err != nil {
+			return fmt.Errorf("Cannot load client certificate `%s` with key `%s`, err=%v", opts.Cert, opts.Key, err)
+		}
+	}
+
+	if opts.CA != "" {
+		ca, err := ioutil.ReadFile(opts.CA)
+		if err != nil {
+			return fmt.Errorf("Cannot read certificate of authority `%s`, err=%v", opts.CA, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(ca) {
+			return fmt.Errorf("Cannot load certificate of authority `%s`, no PEM certificate found", opts.CA)
+		}
+	}
+
+	return nil
+}
+
+// IsConnectionError returns true when the error is caused by failed connection to docker daemon (e.g. the daemon is not running),
+// i.e. the connection is refused, reset or closed by docker daemon or it cannot be established at all; errors reported by docker API
+// (e.g. no such container) and timeouts of requests to docker daemon which is busy are not connection errors
+func IsConnectionError(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *url.Error:
+		return IsConnectionError(e.Err)
+	case *net.OpError:
+		if e.Op == "dial" {
+			return true
+		}
+		return !e.Timeout() && IsConnectionError(e.Err)
+	case *os.SyscallError:
+		return IsConnectionError(e.Err)
+	case syscall.Errno:
+		return e == syscall.ECONNREFUSED || e == syscall.ECONNRESET
+	}
+
+	return err == docker.ErrConnectionRefused || err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
 // NewDockerClient returns dockerClient instance ready for communication with the server endpoint `unix:///var/run/docker.sock`
 func NewDockerClient(endpoint string) (*DockerClient, error) {
 	client, err := docker.NewClient(endpoint)
@@ -70,17 +191,49 @@ func NewDockerClient(endpoint string) (*DockerClient, error) {
 		return nil, fmt.Errorf("Cannot initialize docker client instance with the given endpoint `%s`, err=%v", endpoint, err)
 	}
 
-	err = client.Ping()
+	return newDockerClient(client, endpoint)
+}
+
+// NewDockerTLSClient returns dockerClient instance ready for communication with the server endpoint secured by TLS
+func NewDockerTLSClient(endpoint string, opts TLSOptions) (*DockerClient, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// certificate of the server is not verified by go-dockerclient when certificate of authority is not given
+	ca := opts.CA
+	if !opts.Verify {
+		ca = ""
+	}
+	client, err := docker.NewTLSClient(endpoint, opts.Cert, opts.Key, ca)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot initialize docker TLS client instance with the given endpoint `%s`, err=%v", endpoint, err)
+	}
+	if opts.Verify {
+		// TLS config is shared with transport of HTTP client, certificate of the server is verified with system roots when CA is empty
+		client.TLSConfig.InsecureSkipVerify = false
+	}
+
+	return newDockerClient(client, endpoint)
+}
+
+// newDockerClient returns dockerClient instance for the given go-dockerclient instance when the server responds
+func newDockerClient(client *docker.Client, endpoint string) (*DockerClient, error) {
+	err := client.Ping()
 	if err != nil {
 		return nil, err
 	}
 
 	dc := &DockerClient{
-		cl:           client,
-		inspectCache: map[string]*docker.Container{},
+		cl:       client,
+		endpoint: endpoint,
+		sizes:    map[string]containerSize{},
+		events:   newEventsRecorder(),
+		closed:   make(chan struct{}),
 	}
+	dc.inspectCache = newInspectCache(dc.cl.InspectContainer, dc.inspectHostConfigExtras, inspectCacheTTL, inspectCacheSize)
 
-	config.DockerVersion, err = dc.version()
+	dc.version, err = dc.getVersion()
 	if err != nil {
 		return nil, err
 	}
@@ -90,21 +243,47 @@ func NewDockerClient(endpoint string) (*DockerClient, error) {
 
 // InspectContainer returns details information about running container
 func (dc *DockerClient) InspectContainer(id string) (*docker.Container, error) {
-	dc.inspectMutex.Lock()
-	defer dc.inspectMutex.Unlock()
+	return dc.inspectCache.get(id)
+}
+
+// ReinspectContainer returns details information about running container, cached inspect info is not used
+// (e.g. when the cached process of the container does not exist anymore)
+func (dc *DockerClient) ReinspectContainer(id string) (*docker.Container, error) {
+	return dc.inspectCache.refresh(id)
+}
+
+// GetHostConfigExtras returns settings of the container which are not decoded by go-dockerclient (e.g. CPU limit given
+// as the number of CPUs), they are requested once per inspect info of the container and they expire together with it
+func (dc *DockerClient) GetHostConfigExtras(id string) (*HostConfigExtras, error) {
+	return dc.inspectCache.getExtras(id)
+}
 
-	// check if the inspect info is already stored in inspectCache
-	if info, haveInfo := dc.inspectCache[id]; haveInfo {
-		return info, nil
+// getAPIURL returns URL of docker API path for the given endpoint, host of URL is not used for unix socket
+// and TCP endpoint is accessed with HTTPS when TLS is used
+func getAPIURL(endpoint, path string, useTLS bool) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "tcp://" + endpoint
 	}
 
-	info, err := dc.cl.InspectContainer(id)
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	if useTLS && u.Scheme != "unix" {
+		u.Scheme = "https"
 	}
-	dc.inspectCache[id] = info
 
-	return info, nil
+	switch u.Scheme {
+	case "unix":
+		return "http://docker" + path, nil
+	case "tcp", "http":
+		return "http://" + u.Host + path, nil
+	case "https":
+		return "https://" + u.Host + path, nil
+	}
+
+	return "", fmt.Errorf("Unsupported scheme of docker endpoint %s", endpoint)
 }
 
 // GetDockerParam returns given map of parameter/value from running docker engine
@@ -126,6 +305,76 @@ func (dc *DockerClient) GetDockerParams(params ...string) (map[string]string, er
 	return vals, nil
 }
 
+// GetDockerVersion returns major and minor version of docker engine which the client is connected to
+func (dc *DockerClient) GetDockerVersion() []int {
+	return dc.version
+}
+
+// GetDaemonInfo returns information about docker engine and objects managed by it
+func (dc *DockerClient) GetDaemonInfo() (*DaemonInfo, error) {
+	info, err := dc.cl.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := dc.cl.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	return daemonInfoFromEnv(info, version), nil
+}
+
+// daemonInfoFromEnv returns information about docker engine from output of `docker info` and `docker version`,
+// counts of containers by their state are reported since API 1.24, they are 0 for older versions
+func daemonInfoFromEnv(info, version *docker.Env) *DaemonInfo {
+	return &DaemonInfo{
+		ContainersRunning: getEnvUint(info, "ContainersRunning"),
+		ContainersPaused:  getEnvUint(info, "ContainersPaused"),
+		ContainersStopped: getEnvUint(info, "ContainersStopped"),
+		Images:            getEnvUint(info, "Images"),
+		Goroutines:        getEnvUint(info, "NGoroutines"),
+		FileDescriptors:   getEnvUint(info, "NFd"),
+		EventsListeners:   getEnvUint(info, "NEventsListener"),
+		MemTotal:          getEnvUint(info, "MemTotal"),
+		NCPU:              getEnvUint(info, "NCPU"),
+		Warnings:          uint64(len(info.GetList("Warnings"))),
+		Version:           version.Get("Version"),
+		APIVersion:        version.Get("ApiVersion"),
+	}
+}
+
+// getEnvUint returns value of the given key, 0 when the key is missing (value -1 is returned by docker.Env) or invalid
+func getEnvUint(env *docker.Env, key string) uint64 {
+	if value := env.GetInt64(key); value > 0 {
+		return uint64(value)
+	}
+	return 0
+}
+
+// ListNetworks returns details of all docker networks including containers attached to them
+func (dc *DockerClient) ListNetworks() ([]docker.Network, error) {
+	networkList, err := dc.cl.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	// list of networks does not contain attached containers, they are available in details of each network
+	networks := make([]docker.Network, 0, len(networkList))
+	for _, n := range networkList {
+		network, err := dc.cl.NetworkInfo(n.ID)
+		if err != nil {
+			if _, removed := err.(*docker.NoSuchNetwork); removed {
+				continue
+			}
+			return nil, err
+		}
+		networks = append(networks, *network)
+	}
+
+	return networks, nil
+}
+
 // GetShortID returns short container ID (12 chars)
 func GetShortID(dockerID string) (string, error) {
 	if dockerID == "root" {
@@ -140,20 +389,26 @@ func GetShortID(dockerID string) (string, error) {
 }
 
 // ListContainersAsMap returns list of all available docker containers and base information about them (status, uptime, etc.)
-func (dc *DockerClient) ListContainersAsMap() (map[string]*ContainerData, error) {
+func (dc *DockerClient) ListContainersAsMap(opts ListOptions) (map[string]*ContainerData, error) {
 	containers := make(map[string]*ContainerData)
 
-	containerList, err := dc.cl.ListContainers(docker.ListContainersOptions{})
+	listOpts := docker.ListContainersOptions{All: opts.All}
+	if len(opts.Statuses) > 0 {
+		listOpts.Filters = map[string][]string{"status": opts.Statuses}
+	}
 
+	containerList, err := dc.listContainers(opts, listOpts)
 	if err != nil {
 		return nil, err
 	}
 
+	states := make(map[string]string, len(containerList))
 	for _, c := range containerList {
 		shortID, err := GetShortID(c.ID)
 		if err != nil {
 			return nil, err
 		}
+		states[shortID] = containerState(c)
 
 		spec := Specification{
 			Status:     c.Status,
@@ -168,11 +423,16 @@ func (dc *DockerClient) ListContainersAsMap() (map[string]*ContainerData, error)
 			ID:            c.ID,
 			Specification: spec,
 			Stats:         NewStatistics(),
+			Stopped:       !isRunning(c),
 		}
 
 		containers[shortID] = &containerData
 	}
 
+	dc.inspectCache.sync(states)
+	dc.setSizes(containers, listOpts, opts.SizeInterval)
+	dc.setImages(containers)
+
 	if len(containers) == 0 {
 		log.WithFields(log.Fields{
 			"block":    "client",
@@ -185,8 +445,95 @@ func (dc *DockerClient) ListContainersAsMap() (map[string]*ContainerData, error)
 	return containers, nil
 }
 
+// setSizes sets sizes of containers from cache, the cache is refreshed in background when it is older than the given interval
+func (dc *DockerClient) setSizes(containers map[string]*ContainerData, listOpts docker.ListContainersOptions, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	dc.sizesMutex.Lock()
+	defer dc.sizesMutex.Unlock()
+
+	if !dc.sizesRefreshing && time.Since(dc.sizesUpdated) >= interval {
+		dc.sizesRefreshing = true
+		listOpts.Size = true
+		go dc.refreshSizes(listOpts)
+	}
+
+	for id, data := range containers {
+		if size, ok := dc.sizes[id]; ok {
+			data.Specification.SizeRw = size.rw
+			data.Specification.SizeRootFs = size.rootFs
+		}
+	}
+}
+
+// refreshSizes lists containers with their sizes computed by docker and stores them in cache
+func (dc *DockerClient) refreshSizes(listOpts docker.ListContainersOptions) {
+	sizes := map[string]containerSize{}
+	containerList, err := dc.cl.ListContainers(listOpts)
+	if err == nil {
+		for _, c := range containerList {
+			if shortID, err := GetShortID(c.ID); err == nil {
+				sizes[shortID] = containerSize{rw: c.SizeRw, rootFs: c.SizeRootFs}
+			}
+		}
+	} else {
+		log.WithFields(log.Fields{
+			"block":    "client",
+			"function": "refreshSizes",
+		}).Errorf("Unable to compute sizes of containers: %s", err)
+	}
+
+	dc.sizesMutex.Lock()
+	defer dc.sizesMutex.Unlock()
+
+	// when computing sizes failed, the last known sizes are kept and the next attempt is made after the interval
+	if err == nil {
+		dc.sizes = sizes
+	}
+	dc.sizesUpdated = time.Now()
+	dc.sizesRefreshing = false
+}
+
+// containerState returns state of the listed container (e.g. running or exited), it is derived from status of the container
+// for older versions of docker which do not list its state
+func containerState(c docker.APIContainers) string {
+	if c.State != "" {
+		return c.State
+	}
+
+	switch {
+	case strings.HasSuffix(c.Status, "(Paused)"):
+		return "paused"
+	case strings.HasPrefix(c.Status, "Up"):
+		return "running"
+	case strings.HasPrefix(c.Status, "Restarting"):
+		return "restarting"
+	case c.Status == "Created":
+		return "created"
+	case c.Status == "Dead":
+		return "dead"
+	}
+	return "exited"
+}
+
+// isRunning returns true if the listed container is running (paused container is still running)
+func isRunning(c docker.APIContainers) bool {
+	if c.State == "" {
+		// state of container is not listed by older versions of docker
+		return strings.HasPrefix(c.Status, "Up")
+	}
+	return c.State == "running" || c.State == "paused"
+}
+
 // FindCgroupMountpoint returns cgroup mountpoint of a given subsystem
 func (dc *DockerClient) FindCgroupMountpoint(procfs string, subsystem string) (string, error) {
+	return FindCgroupMountpoint(procfs, subsystem)
+}
+
+// FindCgroupMountpoint returns cgroup mountpoint of a given subsystem on host, it does not need docker daemon
+func FindCgroupMountpoint(procfs string, subsystem string) (string, error) {
 	f, err := os.Open(filepath.Join(procfs, "self/mountinfo"))
 	if err != nil {
 		return "", err
@@ -237,8 +584,8 @@ func (dc *DockerClient) FindControllerMountpoint(subsystem, pid, procfs string)
 
 }
 
-// version returns version of docker engine
-func (dc *DockerClient) version() (version []int, _ error) {
+// getVersion returns version of docker engine
+func (dc *DockerClient) getVersion() (version []int, _ error) {
 	version = []int{0, 0}
 	env, err := dc.cl.Version()
 	if err != nil {
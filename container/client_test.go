@@ -0,0 +1,115 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDaemonInfo(t *testing.T) {
+	Convey("Get information about docker daemon", t, func() {
+		info := &docker.Env{}
+		info.SetInt("ContainersRunning", 3)
+		info.SetInt("ContainersPaused", 1)
+		info.SetInt("ContainersStopped", 2)
+		info.SetInt("Images", 12)
+		info.SetInt("NGoroutines", 64)
+		info.SetInt("NFd", 40)
+		info.SetInt("NEventsListener", 1)
+		info.SetInt64("MemTotal", 8371892224)
+		info.SetInt("NCPU", 8)
+		info.SetList("Warnings", []string{"WARNING: No swap limit support", "WARNING: bridge-nf-call-iptables is disabled"})
+
+		version := &docker.Env{}
+		version.Set("Version", "17.03.1-ce")
+		version.Set("ApiVersion", "1.27")
+
+		Convey("successful reading of docker info and version", func() {
+			So(daemonInfoFromEnv(info, version), ShouldResemble, &DaemonInfo{
+				ContainersRunning: 3,
+				ContainersPaused:  1,
+				ContainersStopped: 2,
+				Images:            12,
+				Goroutines:        64,
+				FileDescriptors:   40,
+				EventsListeners:   1,
+				MemTotal:          8371892224,
+				NCPU:              8,
+				Warnings:          2,
+				Version:           "17.03.1-ce",
+				APIVersion:        "1.27",
+			})
+		})
+
+		Convey("counts are 0 when they are not reported by older docker engine", func() {
+			daemon := daemonInfoFromEnv(&docker.Env{}, version)
+			So(daemon.ContainersRunning, ShouldEqual, 0)
+			So(daemon.Warnings, ShouldEqual, 0)
+			So(daemon.Version, ShouldEqual, "17.03.1-ce")
+		})
+	})
+}
+
+// timeoutError is an error of network operation which has timed out
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+func TestIsConnectionError(t *testing.T) {
+	Convey("Recognize errors of connection to docker daemon", t, func() {
+		Convey("docker daemon which is not running is a connection error", func() {
+			So(IsConnectionError(docker.ErrConnectionRefused), ShouldBeTrue)
+			So(IsConnectionError(&url.Error{Op: "Get", URL: "http://docker/info", Err: &net.OpError{Op: "dial", Net: "unix", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ENOENT}}}), ShouldBeTrue)
+			So(IsConnectionError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("i/o timeout")}), ShouldBeTrue)
+		})
+
+		Convey("connection refused, reset or closed by docker daemon is a connection error", func() {
+			So(IsConnectionError(&url.Error{Op: "Get", URL: "http://docker/info", Err: &net.OpError{Op: "read", Net: "unix", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}}), ShouldBeTrue)
+			So(IsConnectionError(&net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}), ShouldBeTrue)
+			So(IsConnectionError(&url.Error{Op: "Get", URL: "http://docker/info", Err: io.EOF}), ShouldBeTrue)
+			So(IsConnectionError(io.ErrUnexpectedEOF), ShouldBeTrue)
+		})
+
+		Convey("timeout of request to docker daemon which is busy is not a connection error", func() {
+			So(IsConnectionError(&url.Error{Op: "Get", URL: "http://docker/containers/json", Err: &timeoutError{}}), ShouldBeFalse)
+			So(IsConnectionError(&net.OpError{Op: "read", Net: "unix", Err: &timeoutError{}}), ShouldBeFalse)
+		})
+
+		Convey("errors reported by docker API are not connection errors", func() {
+			So(IsConnectionError(nil), ShouldBeFalse)
+			So(IsConnectionError(&docker.Error{Status: 404, Message: "No such container"}), ShouldBeFalse)
+			So(IsConnectionError(&docker.NoSuchContainer{ID: "a26c852ce22c"}), ShouldBeFalse)
+			So(IsConnectionError(errors.New("Disk usage of docker objects is not computed yet")), ShouldBeFalse)
+		})
+	})
+}
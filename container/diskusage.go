@@ -0,0 +1,210 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// systemDF is output of docker API endpoint /system/df (available since API 1.25, build cache since API 1.31)
+type systemDF struct {
+	LayersSize int64
+	Images     []struct {
+		Size        int64
+		SharedSize  int64
+		VirtualSize int64
+		Containers  int64
+	}
+	Containers []struct {
+		SizeRw int64
+		State  string
+	}
+	Volumes []struct {
+		Name      string
+		UsageData *struct {
+			Size     int64
+			RefCount int64
+		}
+	}
+	BuildCache []struct {
+		Size   int64
+		InUse  bool
+		Shared bool
+	}
+}
+
+// diskUsageCache holds the last disk usage of docker objects, it is computed by docker only on demand as it is expensive
+type diskUsageCache struct {
+	mutex      sync.Mutex
+	usage      *DiskUsage
+	updated    time.Time
+	refreshing bool
+}
+
+// GetDiskUsage returns disk usage of docker objects (as `docker system df -v`), the result is refreshed in background
+// when it is older than the given interval; an error is returned until disk usage is computed for the first time
+func (dc *DockerClient) GetDiskUsage(interval time.Duration) (*DiskUsage, error) {
+	cache := &dc.diskUsage
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.refreshing && time.Since(cache.updated) >= interval {
+		cache.refreshing = true
+		go dc.refreshDiskUsage()
+	}
+
+	if cache.usage == nil {
+		return nil, errors.New("Disk usage of docker objects is not computed yet")
+	}
+
+	return cache.usage, nil
+}
+
+// refreshDiskUsage computes disk usage of docker objects and stores it in cache
+func (dc *DockerClient) refreshDiskUsage() {
+	usage, err := dc.systemDiskUsage()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"block":    "client",
+			"function": "refreshDiskUsage",
+		}).Errorf("Unable to compute disk usage of docker objects: %s", err)
+	}
+
+	cache := &dc.diskUsage
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// when computing disk usage failed, the last known disk usage is kept and the next attempt is made after the interval
+	if err == nil {
+		cache.usage = usage
+	}
+	cache.updated = time.Now()
+	cache.refreshing = false
+}
+
+// systemDiskUsage requests disk usage of docker objects, the endpoint is not supported by go-dockerclient
+// so it is requested with HTTP client of go-dockerclient which is already set for the docker endpoint
+func (dc *DockerClient) systemDiskUsage() (*DiskUsage, error) {
+	apiURL, err := getAPIURL(dc.endpoint, "/system/df", dc.cl.TLSConfig != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dc.cl.HTTPClient.Get(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Unexpected response of docker API %s: %s", apiURL, resp.Status)
+	}
+
+	df := systemDF{}
+	if err := json.NewDecoder(resp.Body).Decode(&df); err != nil {
+		return nil, err
+	}
+
+	return diskUsageFromDF(df), nil
+}
+
+// diskUsageFromDF returns totals and reclaimable space of docker objects computed in the same way as by `docker system df`
+func diskUsageFromDF(df systemDF) *DiskUsage {
+	usage := &DiskUsage{
+		Images: DiskUsageSummary{
+			Count: uint64(len(df.Images)),
+			Total: nonNegative(df.LayersSize),
+		},
+		Containers: DiskUsageSummary{Count: uint64(len(df.Containers))},
+		Volumes:    DiskUsageSummary{Count: uint64(len(df.Volumes))},
+		BuildCache: DiskUsageSummary{Count: uint64(len(df.BuildCache))},
+		Volume:     map[string]VolumeUsage{},
+	}
+
+	// space used by images which are used by containers, shared layers are counted only once in total size
+	var usedByImages int64
+	for _, image := range df.Images {
+		if image.Containers > 0 {
+			usage.Images.Active++
+			if image.VirtualSize >= 0 && image.SharedSize >= 0 {
+				usedByImages += image.VirtualSize - image.SharedSize
+			}
+		}
+	}
+	usage.Images.Reclaimable = nonNegative(df.LayersSize - usedByImages)
+
+	for _, c := range df.Containers {
+		usage.Containers.Total += nonNegative(c.SizeRw)
+		if c.State == "running" || c.State == "paused" || c.State == "restarting" {
+			usage.Containers.Active++
+		} else {
+			usage.Containers.Reclaimable += nonNegative(c.SizeRw)
+		}
+	}
+
+	for _, v := range df.Volumes {
+		// usage of volume is not available e.g. for volumes of other drivers than local
+		if v.UsageData == nil {
+			continue
+		}
+		size := nonNegative(v.UsageData.Size)
+		refCount := nonNegative(v.UsageData.RefCount)
+
+		usage.Volume[v.Name] = VolumeUsage{Size: size, RefCount: refCount}
+		usage.Volumes.Total += size
+		if refCount > 0 {
+			usage.Volumes.Active++
+		} else {
+			usage.Volumes.Reclaimable += size
+		}
+	}
+
+	for _, bc := range df.BuildCache {
+		if bc.InUse {
+			usage.BuildCache.Active++
+		}
+		// shared build cache is counted in size of images
+		if bc.Shared {
+			continue
+		}
+		usage.BuildCache.Total += nonNegative(bc.Size)
+		if !bc.InUse {
+			usage.BuildCache.Reclaimable += nonNegative(bc.Size)
+		}
+	}
+
+	return usage
+}
+
+// nonNegative returns the given size, 0 when the size is not available (docker reports it as -1)
+func nonNegative(size int64) uint64 {
+	if size < 0 {
+		return 0
+	}
+	return uint64(size)
+}
@@ -0,0 +1,147 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const mockSystemDF = `{
+	"LayersSize": 1000,
+	"Images": [
+		{"Id": "sha256:e4e6d42c70b3", "Size": 600, "SharedSize": 100, "VirtualSize": 600, "Containers": 2},
+		{"Id": "sha256:0f4e1b3c9d2a", "Size": 500, "SharedSize": 100, "VirtualSize": 500, "Containers": 0}
+	],
+	"Containers": [
+		{"Id": "a26c852ce22c", "SizeRw": 30, "State": "running"},
+		{"Id": "b4a1e9f07c3d", "SizeRw": 20, "State": "exited"},
+		{"Id": "c48e074e044e", "SizeRw": -1, "State": "created"}
+	],
+	"Volumes": [
+		{"Name": "pgdata", "UsageData": {"Size": 4096, "RefCount": 1}},
+		{"Name": "cache", "UsageData": {"Size": 512, "RefCount": 0}},
+		{"Name": "remote", "UsageData": {"Size": -1, "RefCount": -1}},
+		{"Name": "unknown"}
+	],
+	"BuildCache": [
+		{"ID": "ndlpt0hhvkqc", "Size": 200, "InUse": false, "Shared": false},
+		{"ID": "u0j1x3ka5zt8", "Size": 300, "InUse": true, "Shared": false},
+		{"ID": "xk0p7gm2a1rd", "Size": 400, "InUse": false, "Shared": true}
+	]
+}`
+
+func TestDiskUsage(t *testing.T) {
+	Convey("Get disk usage of docker objects", t, func() {
+		Convey("totals and reclaimable space are computed as by docker system df", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/system/df" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(mockSystemDF))
+			}))
+			defer server.Close()
+
+			cl, err := docker.NewClient(server.URL)
+			So(err, ShouldBeNil)
+			dc := &DockerClient{cl: cl, endpoint: server.URL}
+
+			usage, err := dc.systemDiskUsage()
+			So(err, ShouldBeNil)
+			So(usage.Images, ShouldResemble, DiskUsageSummary{Count: 2, Active: 1, Total: 1000, Reclaimable: 500})
+			So(usage.Containers, ShouldResemble, DiskUsageSummary{Count: 3, Active: 1, Total: 50, Reclaimable: 20})
+			So(usage.Volumes, ShouldResemble, DiskUsageSummary{Count: 4, Active: 1, Total: 4608, Reclaimable: 512})
+			So(usage.BuildCache, ShouldResemble, DiskUsageSummary{Count: 3, Active: 1, Total: 500, Reclaimable: 200})
+			So(usage.Volume, ShouldResemble, map[string]VolumeUsage{
+				"pgdata": {Size: 4096, RefCount: 1},
+				"cache":  {Size: 512, RefCount: 0},
+				"remote": {Size: 0, RefCount: 0},
+			})
+		})
+
+		Convey("disk usage is refreshed in background and an error is returned until it is computed", func() {
+			requests := make(chan struct{}, 10)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(mockSystemDF))
+				requests <- struct{}{}
+			}))
+			defer server.Close()
+
+			cl, err := docker.NewClient(server.URL)
+			So(err, ShouldBeNil)
+			dc := &DockerClient{cl: cl, endpoint: server.URL}
+
+			_, err = dc.GetDiskUsage(time.Hour)
+			So(err, ShouldNotBeNil)
+			<-requests
+
+			var usage *DiskUsage
+			for i := 0; i < 100 && usage == nil; i++ {
+				time.Sleep(10 * time.Millisecond)
+				usage, _ = dc.GetDiskUsage(time.Hour)
+			}
+			So(usage, ShouldNotBeNil)
+			So(usage.Images.Total, ShouldEqual, 1000)
+			// disk usage is not computed again until the interval elapses
+			So(len(requests), ShouldEqual, 0)
+		})
+
+		Convey("return an error when docker engine does not support disk usage", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			defer server.Close()
+
+			cl, err := docker.NewClient(server.URL)
+			So(err, ShouldBeNil)
+			dc := &DockerClient{cl: cl, endpoint: server.URL}
+
+			_, err = dc.systemDiskUsage()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("URL of docker API is built for the given endpoint", func() {
+			url, err := getAPIURL("unix:///var/run/docker.sock", "/system/df", false)
+			So(err, ShouldBeNil)
+			So(url, ShouldEqual, "http://docker/system/df")
+
+			url, err = getAPIURL("tcp://10.0.0.5:2375", "/system/df", false)
+			So(err, ShouldBeNil)
+			So(url, ShouldEqual, "http://10.0.0.5:2375/system/df")
+
+			url, err = getAPIURL("https://10.0.0.5:2376", "/system/df", false)
+			So(err, ShouldBeNil)
+			So(url, ShouldEqual, "https://10.0.0.5:2376/system/df")
+
+			url, err = getAPIURL("tcp://10.0.0.5:2376", "/system/df", true)
+			So(err, ShouldBeNil)
+			So(url, ShouldEqual, "https://10.0.0.5:2376/system/df")
+
+			_, err = getAPIURL("npipe:////./pipe/docker_engine", "/system/df", false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,322 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// size of buffer for docker events, events are handled one by one as some of them require listing of the container
+const eventsBufferSize = 100
+
+// GetEventsStats returns numbers of docker events per container (keyed by short id) and totals for host (keyed by "root"),
+// docker events are monitored since the first call, so only events which occur later are counted
+func (dc *DockerClient) GetEventsStats() (map[string]EventsStats, error) {
+	if err := dc.subscribeEvents(); err != nil {
+		return nil, err
+	}
+	return dc.events.stats(), nil
+}
+
+// AddEventHandler registers handler which is called with id of the container and action of each its docker event,
+// handler is called from the goroutine which monitors docker events, so it should not block
+func (dc *DockerClient) AddEventHandler(handler func(id, action string)) error {
+	if err := dc.subscribeEvents(); err != nil {
+		return err
+	}
+
+	dc.eventsMutex.Lock()
+	defer dc.eventsMutex.Unlock()
+
+	dc.handlers = append(dc.handlers, handler)
+
+	return nil
+}
+
+// subscribeEvents starts monitoring of docker events unless it is already running
+func (dc *DockerClient) subscribeEvents() error {
+	dc.eventsMutex.Lock()
+	defer dc.eventsMutex.Unlock()
+
+	if dc.subscribed {
+		return nil
+	}
+	if dc.isClosed() {
+		return errors.New("Docker client is closed")
+	}
+
+	events := make(chan *docker.APIEvents, eventsBufferSize)
+	if err := dc.cl.AddEventListener(events); err != nil {
+		return err
+	}
+	dc.subscribed = true
+	go dc.handleEvents(events)
+
+	return nil
+}
+
+// Close stops monitoring of docker events, the client should not be used anymore
+func (dc *DockerClient) Close() error {
+	dc.eventsMutex.Lock()
+	defer dc.eventsMutex.Unlock()
+
+	if dc.closed != nil && !dc.isClosed() {
+		close(dc.closed)
+	}
+
+	return nil
+}
+
+// isClosed returns true when the client has been closed
+func (dc *DockerClient) isClosed() bool {
+	select {
+	case <-dc.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleEvents records events of containers and updates the inventory, until monitoring of docker events stops
+// or the client is closed
+func (dc *DockerClient) handleEvents(events chan *docker.APIEvents) {
+	for {
+		var event *docker.APIEvents
+		open := false
+		select {
+		case event, open = <-events:
+		case <-dc.closed:
+			dc.cl.RemoveEventListener(events)
+			return
+		}
+		if !open {
+			break
+		}
+
+		id, action := containerEvent(event)
+		if id == "" {
+			continue
+		}
+
+		dc.events.record(id, action, eventImage(event), eventTime(event))
+		dc.updateInventoryOnEvent(id, action)
+
+		dc.eventsMutex.Lock()
+		handlers := dc.handlers
+		dc.eventsMutex.Unlock()
+		for _, handler := range handlers {
+			handler(id, action)
+		}
+	}
+
+	// listener is closed when monitoring of events stops (e.g. docker engine has been restarted),
+	// the next call subscribes again and the inventory lists all containers as events might have been missed
+	dc.eventsMutex.Lock()
+	dc.subscribed = false
+	dc.eventsMutex.Unlock()
+	dc.resetInventory()
+
+	log.WithFields(log.Fields{
+		"block":    "client",
+		"function": "handleEvents",
+	}).Warnf("Monitoring of docker events has stopped")
+}
+
+// containerEvent returns id of the container and action of the event, both are empty for events of other objects (e.g. networks);
+// details of action are omitted, e.g. `exec_create: sh -c ls` is returned as `exec_create`
+func containerEvent(event *docker.APIEvents) (id string, action string) {
+	// fields Type, Action and Actor are available since API 1.22
+	if event.Type != "" {
+		if event.Type != "container" {
+			return "", ""
+		}
+		id, action = event.Actor.ID, event.Action
+	} else {
+		id, action = event.ID, event.Status
+	}
+
+	return id, strings.TrimSpace(strings.SplitN(action, ":", 2)[0])
+}
+
+// eventImage returns image of the container which the event refers to
+func eventImage(event *docker.APIEvents) string {
+	if image, ok := event.Actor.Attributes["image"]; ok {
+		return image
+	}
+	return event.From
+}
+
+// eventTime returns time when the event occurred
+func eventTime(event *docker.APIEvents) time.Time {
+	if event.TimeNano > 0 {
+		return time.Unix(0, event.TimeNano)
+	}
+	return time.Unix(event.Time, 0)
+}
+
+// eventsRecorder counts docker events per container, per image and in total, and measures durations of lifecycle transitions
+type eventsRecorder struct {
+	mutex      sync.Mutex
+	containers map[string]*EventsStats
+	total      EventsStats
+	// image run by containers which have not been destroyed yet
+	images map[string]string
+	// time of the last create and the first kill event of containers which have not started or died yet
+	created map[string]time.Time
+	killed  map[string]time.Time
+}
+
+func newEventsRecorder() *eventsRecorder {
+	return &eventsRecorder{
+		containers: map[string]*EventsStats{},
+		total:      EventsStats{Image: map[string]EventCounters{}},
+		images:     map[string]string{},
+		created:    map[string]time.Time{},
+		killed:     map[string]time.Time{},
+	}
+}
+
+// record updates statistics with the event of the container running the given image,
+// statistics of a container are dropped when the container is destroyed, but they stay included in totals,
+// totals of an image are dropped when the last container running it is destroyed
+func (r *eventsRecorder) record(id, action, image string, at time.Time) {
+	shortID, err := GetShortID(id)
+	if err != nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if action == "destroy" {
+		if recorded, ok := r.images[shortID]; ok {
+			image = recorded
+		}
+		delete(r.containers, shortID)
+		delete(r.images, shortID)
+		delete(r.created, shortID)
+		delete(r.killed, shortID)
+		r.pruneImage(image)
+		return
+	}
+
+	if image != "" {
+		r.images[shortID] = image
+	}
+
+	stats, ok := r.containers[shortID]
+	if !ok {
+		stats = &EventsStats{}
+		r.containers[shortID] = stats
+	}
+
+	switch action {
+	case "create":
+		r.created[shortID] = at
+	case "start":
+		if created, ok := r.created[shortID]; ok {
+			stats.Duration.CreateToStart = at.Sub(created).Seconds()
+			r.total.Duration.CreateToStart = stats.Duration.CreateToStart
+			delete(r.created, shortID)
+		}
+	case "kill":
+		// container can be killed several times (e.g. SIGTERM followed by SIGKILL), the first kill is taken into account
+		if _, ok := r.killed[shortID]; !ok {
+			r.killed[shortID] = at
+		}
+	case "die":
+		if killed, ok := r.killed[shortID]; ok {
+			stats.Duration.KillToDie = at.Sub(killed).Seconds()
+			r.total.Duration.KillToDie = stats.Duration.KillToDie
+			delete(r.killed, shortID)
+		}
+	}
+
+	if !stats.Count.add(action) {
+		return
+	}
+	r.total.Count.add(action)
+	if image != "" {
+		counters := r.total.Image[image]
+		counters.add(action)
+		r.total.Image[image] = counters
+	}
+}
+
+// pruneImage drops totals of the image when no other container running it is known
+func (r *eventsRecorder) pruneImage(image string) {
+	for _, running := range r.images {
+		if running == image {
+			return
+		}
+	}
+	delete(r.total.Image, image)
+}
+
+// stats returns copy of statistics per container and totals for host keyed by "root"
+func (r *eventsRecorder) stats() map[string]EventsStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats := make(map[string]EventsStats, len(r.containers)+1)
+	for id, s := range r.containers {
+		stats[id] = *s
+	}
+
+	total := r.total
+	total.Image = make(map[string]EventCounters, len(r.total.Image))
+	for image, counters := range r.total.Image {
+		total.Image[image] = counters
+	}
+	stats["root"] = total
+
+	return stats
+}
+
+// add increments the counter of the given action, it returns false when the action is not counted
+func (c *EventCounters) add(action string) bool {
+	switch action {
+	case "start":
+		c.Start++
+	case "die":
+		c.Die++
+	case "oom":
+		c.Oom++
+	case "kill":
+		c.Kill++
+	case "restart":
+		c.Restart++
+	case "health_status":
+		c.HealthStatus++
+	case "exec_create":
+		c.ExecCreate++
+	default:
+		return false
+	}
+	return true
+}
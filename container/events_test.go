@@ -0,0 +1,143 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventsRecorder(t *testing.T) {
+	Convey("Record docker events", t, func() {
+		recorder := newEventsRecorder()
+		at := time.Unix(1500000000, 0)
+		webID := "a26c852ce22cbf94f75299b879ccb0d94427aa265778e1e9d6e6483ffb7837ed"
+		dbID := "b4a1e9f07c3d5c2f0a7e1b0d6c4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e"
+
+		Convey("events are counted per container, per image and in total", func() {
+			recorder.record(webID, "start", "nginx", at)
+			recorder.record(webID, "die", "nginx", at)
+			recorder.record(webID, "start", "nginx", at)
+			recorder.record(webID, "exec_create", "nginx", at)
+			recorder.record(dbID, "oom", "postgres:9.6", at)
+			recorder.record(dbID, "health_status", "postgres:9.6", at)
+
+			stats := recorder.stats()
+			So(stats["a26c852ce22c"].Count, ShouldResemble, EventCounters{Start: 2, Die: 1, ExecCreate: 1})
+			So(stats["b4a1e9f07c3d"].Count, ShouldResemble, EventCounters{Oom: 1, HealthStatus: 1})
+			So(stats["root"].Count, ShouldResemble, EventCounters{Start: 2, Die: 1, Oom: 1, HealthStatus: 1, ExecCreate: 1})
+			So(stats["root"].Image["nginx"], ShouldResemble, EventCounters{Start: 2, Die: 1, ExecCreate: 1})
+			So(stats["root"].Image["postgres:9.6"], ShouldResemble, EventCounters{Oom: 1, HealthStatus: 1})
+			// images are available only for host
+			So(stats["a26c852ce22c"].Image, ShouldBeEmpty)
+		})
+
+		Convey("durations of the latest lifecycle transitions are measured", func() {
+			recorder.record(webID, "create", "nginx", at)
+			recorder.record(webID, "start", "nginx", at.Add(250*time.Millisecond))
+			recorder.record(webID, "kill", "nginx", at.Add(time.Minute))
+			recorder.record(webID, "kill", "nginx", at.Add(time.Minute+10*time.Second))
+			recorder.record(webID, "die", "nginx", at.Add(time.Minute+10500*time.Millisecond))
+
+			stats := recorder.stats()
+			So(stats["a26c852ce22c"].Duration, ShouldResemble, EventDurations{CreateToStart: 0.25, KillToDie: 10.5})
+			So(stats["root"].Duration, ShouldResemble, EventDurations{CreateToStart: 0.25, KillToDie: 10.5})
+
+			// container which dies without being killed keeps the last measured duration
+			recorder.record(webID, "start", "nginx", at.Add(2*time.Minute))
+			recorder.record(webID, "die", "nginx", at.Add(3*time.Minute))
+			So(recorder.stats()["a26c852ce22c"].Duration.KillToDie, ShouldEqual, 10.5)
+		})
+
+		Convey("destroyed container is dropped but stays included in totals", func() {
+			recorder.record(webID, "start", "nginx", at)
+			recorder.record(webID, "destroy", "nginx", at)
+
+			stats := recorder.stats()
+			So(stats, ShouldNotContainKey, "a26c852ce22c")
+			So(stats["root"].Count.Start, ShouldEqual, 1)
+		})
+
+		Convey("totals of image are dropped when the last container running it is destroyed", func() {
+			recorder.record(webID, "start", "nginx", at)
+			recorder.record(dbID, "start", "nginx", at)
+			recorder.record(webID, "destroy", "", at)
+			So(recorder.stats()["root"].Image["nginx"].Start, ShouldEqual, 2)
+
+			recorder.record(dbID, "destroy", "", at)
+			stats := recorder.stats()
+			So(stats["root"].Image, ShouldNotContainKey, "nginx")
+			So(stats["root"].Count.Start, ShouldEqual, 2)
+		})
+
+		Convey("stats are copied, so they are not changed by later events", func() {
+			recorder.record(webID, "start", "nginx", at)
+			stats := recorder.stats()
+			recorder.record(webID, "start", "nginx", at)
+			So(stats["root"].Image["nginx"].Start, ShouldEqual, 1)
+			So(stats["a26c852ce22c"].Count.Start, ShouldEqual, 1)
+		})
+
+		Convey("details of action, image and time are read from the event", func() {
+			event := &docker.APIEvents{
+				Type:     "container",
+				Action:   "exec_create: sh -c ls",
+				Actor:    docker.APIActor{ID: webID, Attributes: map[string]string{"image": "nginx"}},
+				Time:     1500000000,
+				TimeNano: 1500000000250000000,
+			}
+			id, action := containerEvent(event)
+			So(id, ShouldEqual, webID)
+			So(action, ShouldEqual, "exec_create")
+			So(eventImage(event), ShouldEqual, "nginx")
+			So(eventTime(event), ShouldResemble, time.Unix(0, 1500000000250000000))
+
+			old := &docker.APIEvents{ID: dbID, Status: "health_status: healthy", From: "postgres:9.6", Time: 1500000000}
+			id, action = containerEvent(old)
+			So(id, ShouldEqual, dbID)
+			So(action, ShouldEqual, "health_status")
+			So(eventImage(old), ShouldEqual, "postgres:9.6")
+			So(eventTime(old), ShouldResemble, time.Unix(1500000000, 0))
+		})
+	})
+}
+
+func TestCloseClient(t *testing.T) {
+	Convey("Close docker client", t, func() {
+		dc := &DockerClient{closed: make(chan struct{})}
+
+		Convey("docker events are not monitored by closed client", func() {
+			So(dc.Close(), ShouldBeNil)
+			So(dc.AddEventHandler(func(id, action string) {}), ShouldNotBeNil)
+			_, err := dc.GetEventsStats()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("client can be closed several times", func() {
+			So(dc.Close(), ShouldBeNil)
+			So(dc.Close(), ShouldBeNil)
+		})
+	})
+}
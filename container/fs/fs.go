@@ -55,7 +55,6 @@ import (
 	"github.com/moby/moby/pkg/mount"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/intelsdi-x/snap-plugin-collector-docker/config"
 	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
 )
 
@@ -283,7 +282,11 @@ func (du *DiskUsageCollector) GetStats(stats *container.Statistics, opts contain
 
 	if id != "root" {
 		getUserLayerID := func(storageDir, storageDriver, containerID string) (string, error) {
-			dockerVersion := config.DockerVersion
+			// version of docker engine which runs the container, layout of storage is unknown for other versions
+			dockerVersion, ok := opts["docker_version"].([]int)
+			if !ok || len(dockerVersion) < 2 {
+				dockerVersion = []int{0, 0}
+			}
 			if dockerVersion[0] <= userLayerFirstVersionMaj && dockerVersion[1] < userLayerFirstVersionMin {
 				return containerID, nil
 			}
@@ -405,7 +408,7 @@ func (du *DiskUsageCollector) GetStats(stats *container.Statistics, opts contain
 	return nil
 }
 
-//GetGlobalFsInfo returns capacity and free space, in bytes, of all the ext2, ext3, ext4 filesystems on the host.
+// GetGlobalFsInfo returns capacity and free space, in bytes, of all the ext2, ext3, ext4 filesystems on the host.
 func (self *RealFsInfo) GetGlobalFsInfo(procfs string) ([]Fs, error) {
 	return self.GetFsInfoForPath(nil, procfs)
 }
@@ -0,0 +1,229 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// imagesCacheTTL says how long the list of images is used to resolve images of containers before images are listed again
+const imagesCacheTTL = time.Minute
+
+// imagesCache stores the list of images to avoid listing images on each listing of containers
+type imagesCache struct {
+	mutex  sync.Mutex
+	images []docker.APIImages
+	listed time.Time
+}
+
+// GetImagesInventory returns information about docker images keyed by their repo tag (short id for images without tag)
+func (dc *DockerClient) GetImagesInventory() (map[string]ImageInventory, error) {
+	images, err := dc.listImages(true)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := dc.cl.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return imagesInventory(images, containers, time.Now()), nil
+}
+
+// listImages returns images from cache, images are listed again when the cache expires or when refresh is requested
+func (dc *DockerClient) listImages(refresh bool) ([]docker.APIImages, error) {
+	cache := &dc.images
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if refresh || cache.images == nil || time.Since(cache.listed) >= imagesCacheTTL {
+		images, err := dc.cl.ListImages(docker.ListImagesOptions{})
+		if err != nil {
+			return nil, err
+		}
+		cache.images = images
+		cache.listed = time.Now()
+	}
+
+	return cache.images, nil
+}
+
+// setImages sets id and digest of images of the containers, only log error when images cannot be listed;
+// containers of images pulled since images were listed get them when the list of images expires
+func (dc *DockerClient) setImages(containers map[string]*ContainerData) {
+	if len(containers) == 0 {
+		return
+	}
+
+	images, err := dc.listImages(false)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"block":    "client",
+			"function": "setImages",
+		}).Errorf("Unable to list docker images: %s", err)
+		return
+	}
+
+	for _, data := range containers {
+		if image := findImage(images, data.Specification.Image); image != nil {
+			data.Specification.ImageID = image.ID
+			data.Specification.ImageDigest = imageDigest(image, data.Specification.Image)
+		}
+	}
+}
+
+// imagesInventory returns information about the images and their usage by the containers,
+// images are keyed by their first repo tag, images without tag are keyed by short id
+func imagesInventory(images []docker.APIImages, containers []docker.APIContainers, now time.Time) map[string]ImageInventory {
+	used := map[string]uint64{}
+	for _, c := range containers {
+		if image := findImage(images, c.Image); image != nil {
+			used[image.ID]++
+		}
+	}
+
+	inventory := make(map[string]ImageInventory, len(images))
+	for _, image := range images {
+		tags := repoTags(image.RepoTags)
+		digests := repoTags(image.RepoDigests)
+
+		info := ImageInventory{
+			ID:          image.ID,
+			Size:        nonNegative(image.Size),
+			VirtualSize: nonNegative(image.VirtualSize),
+			Containers:  used[image.ID],
+		}
+		if created := time.Unix(image.Created, 0); image.Created > 0 && now.After(created) {
+			info.Age = uint64(now.Sub(created).Seconds())
+		}
+		if len(tags) == 0 && len(digests) == 0 {
+			info.Dangling = 1
+		}
+		if info.Containers == 0 {
+			info.Unused = 1
+		}
+
+		key := imageShortID(image.ID)
+		if len(tags) > 0 {
+			key = tags[0]
+		}
+		inventory[key] = info
+	}
+
+	return inventory
+}
+
+// findImage returns the image which the reference used to create a container refers to,
+// the reference is a repo tag, a repo digest or (short) id of the image; nil is returned when there is no such image
+func findImage(images []docker.APIImages, ref string) *docker.APIImages {
+	if ref == "" {
+		return nil
+	}
+	normalized := normalizeRef(ref)
+	id := strings.TrimPrefix(ref, "sha256:")
+
+	for i := range images {
+		image := &images[i]
+		if image.ID == ref || (len(id) >= 12 && strings.HasPrefix(strings.TrimPrefix(image.ID, "sha256:"), id)) {
+			return image
+		}
+		for _, tag := range image.RepoTags {
+			if tag == normalized {
+				return image
+			}
+		}
+		for _, digest := range image.RepoDigests {
+			if digest == ref {
+				return image
+			}
+		}
+	}
+
+	return nil
+}
+
+// imageDigest returns digest of the image (e.g. sha256:<hash>), the digest from repository of the reference is preferred;
+// empty string is returned for images which have not been pulled or pushed
+func imageDigest(image *docker.APIImages, ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[i+1:]
+	}
+
+	digests := repoTags(image.RepoDigests)
+	if len(digests) == 0 {
+		return ""
+	}
+
+	repo := repository(normalizeRef(ref))
+	for _, digest := range digests {
+		if i := strings.Index(digest, "@"); i >= 0 && digest[:i] == repo {
+			return digest[i+1:]
+		}
+	}
+
+	return digests[0][strings.Index(digests[0], "@")+1:]
+}
+
+// normalizeRef adds default tag `latest` to the reference without tag or digest
+func normalizeRef(ref string) string {
+	if strings.Contains(ref, "@") || strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		return ref
+	}
+	return ref + ":latest"
+}
+
+// repository returns repository of the reference, e.g. `nginx` for `nginx:1.13`
+func repository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+// repoTags returns repo tags (or repo digests) without placeholders `<none>:<none>` and `<none>@<none>` of untagged images
+func repoTags(refs []string) []string {
+	valid := []string{}
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref, "<none>") {
+			valid = append(valid, ref)
+		}
+	}
+	return valid
+}
+
+// imageShortID returns the first 12 characters of image id without algorithm prefix
+func imageShortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,109 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImagesInventory(t *testing.T) {
+	Convey("Get inventory of docker images", t, func() {
+		now := time.Unix(1500000000, 0)
+		images := []docker.APIImages{
+			{
+				ID:          "sha256:e4e6d42c70b3f79c5b67b9b1df5c8e5a1c7d6a2b3c4d5e6f7a8b9c0d1e2f3a4b",
+				RepoTags:    []string{"nginx:1.13", "nginx:latest"},
+				RepoDigests: []string{"nginx@sha256:9fca103a62af6db7f188ac3376c60927db41f88b8d2354bf02d2290a672dc425"},
+				Created:     now.Add(-time.Hour).Unix(),
+				Size:        108958610,
+				VirtualSize: 108958610,
+			},
+			{
+				ID:          "sha256:0f4e1b3c9d2a5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f",
+				RepoTags:    []string{"localhost:5000/app:2.0"},
+				RepoDigests: []string{"app@sha256:1111111111111111111111111111111111111111111111111111111111111111", "localhost:5000/app@sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+				Created:     now.Add(-24 * time.Hour).Unix(),
+				Size:        4096,
+				VirtualSize: 8192,
+			},
+			{
+				ID:          "sha256:5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f0f4e1b3c9d2a5e6f7a8b9c0d1e2f3a4b",
+				RepoTags:    []string{"<none>:<none>"},
+				RepoDigests: []string{"<none>@<none>"},
+				Created:     now.Add(-48 * time.Hour).Unix(),
+				Size:        1024,
+				VirtualSize: -1,
+			},
+		}
+		containers := []docker.APIContainers{
+			{ID: "a26c852ce22c", Image: "nginx"},
+			{ID: "b4a1e9f07c3d", Image: "nginx:1.13"},
+			{ID: "c48e074e044e", Image: "0f4e1b3c9d2a"},
+			{ID: "d5f6a7b8c9d0", Image: "removed:1.0"},
+		}
+
+		Convey("images are keyed by their first repo tag or short id and counted by containers using them", func() {
+			inventory := imagesInventory(images, containers, now)
+			So(len(inventory), ShouldEqual, 3)
+			So(inventory["nginx:1.13"], ShouldResemble, ImageInventory{
+				ID:          images[0].ID,
+				Size:        108958610,
+				VirtualSize: 108958610,
+				Age:         3600,
+				Containers:  2,
+			})
+			So(inventory["localhost:5000/app:2.0"].Containers, ShouldEqual, 1)
+			So(inventory["localhost:5000/app:2.0"].Age, ShouldEqual, 86400)
+			So(inventory["5c6d7e8f9a0b"], ShouldResemble, ImageInventory{
+				ID:       images[2].ID,
+				Size:     1024,
+				Age:      172800,
+				Dangling: 1,
+				Unused:   1,
+			})
+		})
+
+		Convey("images of containers are found by repo tag, repo digest or id", func() {
+			So(findImage(images, "nginx"), ShouldEqual, &images[0])
+			So(findImage(images, "nginx:latest"), ShouldEqual, &images[0])
+			So(findImage(images, "nginx@sha256:9fca103a62af6db7f188ac3376c60927db41f88b8d2354bf02d2290a672dc425"), ShouldEqual, &images[0])
+			So(findImage(images, "localhost:5000/app:2.0"), ShouldEqual, &images[1])
+			So(findImage(images, "sha256:0f4e1b3c9d2a5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f"), ShouldEqual, &images[1])
+			So(findImage(images, "5c6d7e8f9a0b"), ShouldEqual, &images[2])
+			So(findImage(images, "nginx:1.12"), ShouldBeNil)
+			So(findImage(images, "localhost:5000/app"), ShouldBeNil)
+			So(findImage(images, ""), ShouldBeNil)
+		})
+
+		Convey("digest of image is taken from repository of the container's image", func() {
+			So(imageDigest(&images[0], "nginx"), ShouldEqual, "sha256:9fca103a62af6db7f188ac3376c60927db41f88b8d2354bf02d2290a672dc425")
+			So(imageDigest(&images[1], "localhost:5000/app:2.0"), ShouldEqual, "sha256:2222222222222222222222222222222222222222222222222222222222222222")
+			So(imageDigest(&images[1], "0f4e1b3c9d2a"), ShouldEqual, "sha256:1111111111111111111111111111111111111111111111111111111111111111")
+			So(imageDigest(&images[1], "app@sha256:3333333333333333333333333333333333333333333333333333333333333333"), ShouldEqual, "sha256:3333333333333333333333333333333333333333333333333333333333333333")
+			So(imageDigest(&images[2], "5c6d7e8f9a0b"), ShouldBeEmpty)
+		})
+	})
+}
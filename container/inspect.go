@@ -0,0 +1,230 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// inspectCacheTTL says how long inspect info is used before the container is inspected again
+	inspectCacheTTL = 5 * time.Minute
+	// inspectCacheSize is the maximum number of containers which inspect info is cached
+	inspectCacheSize = 4096
+	// defaultHealthcheckInterval is interval of healthcheck probes used by docker when it is not configured for the container
+	defaultHealthcheckInterval = 30 * time.Second
+)
+
+// inspectCache stores output from docker container inspect to avoid inspecting the container on each collection,
+// inspect info is dropped when the container is not listed anymore, when its state changes or when it expires;
+// process of restarted container is revalidated by the caller which refreshes inspect info of the container
+type inspectCache struct {
+	mutex      sync.Mutex
+	entries    map[string]inspectEntry
+	ttl        time.Duration
+	maxEntries int
+	inspect    func(string) (*docker.Container, error)
+	// settings of host config unknown to go-dockerclient are requested separately, only when they are needed
+	inspectExtras func(string) (*HostConfigExtras, error)
+	now           func() time.Time
+}
+
+// inspectEntry holds output from docker container inspect and time of inspection
+type inspectEntry struct {
+	info *docker.Container
+	// nil until settings of host config unknown to go-dockerclient are requested
+	extras    *HostConfigExtras
+	inspected time.Time
+}
+
+// HostConfigExtras holds settings from host config of the container which are not decoded by the vendored version of go-dockerclient
+type HostConfigExtras struct {
+	// CPU limit in units of 10^-9 CPUs (docker run --cpus)
+	NanoCpus int64
+	// OCI runtime of the container, e.g. runc
+	Runtime string
+}
+
+func newInspectCache(inspect func(string) (*docker.Container, error), inspectExtras func(string) (*HostConfigExtras, error), ttl time.Duration, maxEntries int) *inspectCache {
+	return &inspectCache{
+		entries:       map[string]inspectEntry{},
+		ttl:           ttl,
+		maxEntries:    maxEntries,
+		inspect:       inspect,
+		inspectExtras: inspectExtras,
+		now:           time.Now,
+	}
+}
+
+// get returns cached inspect info of the container, the container is inspected when there is none or it has expired
+func (ic *inspectCache) get(id string) (*docker.Container, error) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	if entry, ok := ic.entries[id]; ok && ic.isValid(entry) {
+		return entry.info, nil
+	}
+
+	entry, err := ic.inspectContainer(id)
+	return entry.info, err
+}
+
+// getExtras returns settings from host config of the container which are not known to go-dockerclient,
+// they are requested once per inspect info of the container and they expire together with it
+func (ic *inspectCache) getExtras(id string) (*HostConfigExtras, error) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	entry, ok := ic.entries[id]
+	if !ok || !ic.isValid(entry) {
+		var err error
+		if entry, err = ic.inspectContainer(id); err != nil {
+			return nil, err
+		}
+	}
+	if entry.extras != nil {
+		return entry.extras, nil
+	}
+
+	extras, err := ic.inspectExtras(id)
+	if err != nil {
+		return nil, err
+	}
+	entry.extras = extras
+	ic.entries[id] = entry
+
+	return extras, nil
+}
+
+// refresh inspects the container regardless of cached inspect info
+func (ic *inspectCache) refresh(id string) (*docker.Container, error) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	entry, err := ic.inspectContainer(id)
+	return entry.info, err
+}
+
+// inspectContainer inspects the container and stores its inspect info, must be called with the mutex locked
+func (ic *inspectCache) inspectContainer(id string) (inspectEntry, error) {
+	info, err := ic.inspect(id)
+	if err != nil {
+		delete(ic.entries, id)
+		return inspectEntry{}, err
+	}
+
+	entry := inspectEntry{info: info, inspected: ic.now()}
+	ic.entries[id] = entry
+	ic.evict()
+
+	return entry, nil
+}
+
+// isValid returns true if inspect info has not expired, results of healthcheck probes are refreshed with each probe,
+// so inspect info of container with healthcheck expires after the interval of probes
+func (ic *inspectCache) isValid(entry inspectEntry) bool {
+	ttl := ic.ttl
+	if entry.info.State.Health.Status != "" {
+		interval := defaultHealthcheckInterval
+		if entry.info.Config != nil && entry.info.Config.Healthcheck != nil && entry.info.Config.Healthcheck.Interval > 0 {
+			interval = entry.info.Config.Healthcheck.Interval
+		}
+		if interval < ttl {
+			ttl = interval
+		}
+	}
+	return ic.now().Sub(entry.inspected) < ttl
+}
+
+// evict drops the oldest inspect info until the number of cached containers does not exceed the bound
+func (ic *inspectCache) evict() {
+	for len(ic.entries) > ic.maxEntries {
+		oldest := ""
+		for id, entry := range ic.entries {
+			if oldest == "" || entry.inspected.Before(ic.entries[oldest].inspected) {
+				oldest = id
+			}
+		}
+		delete(ic.entries, oldest)
+	}
+}
+
+// sync drops inspect info of containers which are not listed anymore or which state has changed since
+// they were inspected (e.g. the container has exited or has been paused), so the next inspect returns current state of the container
+func (ic *inspectCache) sync(states map[string]string) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	for id, entry := range ic.entries {
+		if state, listed := states[id]; !listed || state != entry.info.State.StateString() {
+			delete(ic.entries, id)
+		}
+	}
+}
+
+// drop drops inspect info of the container
+func (ic *inspectCache) drop(id string) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	delete(ic.entries, id)
+}
+
+// inspectHostConfigExtras requests inspect info of the container from docker API and decodes only settings of host config
+// which go-dockerclient does not know, container id is hexadecimal, so it is not escaped in the path
+func (dc *DockerClient) inspectHostConfigExtras(id string) (*HostConfigExtras, error) {
+	apiURL, err := getAPIURL(dc.endpoint, "/containers/"+id+"/json", dc.cl.TLSConfig != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dc.cl.HTTPClient.Get(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, &docker.NoSuchContainer{ID: id}
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, &docker.Error{Status: resp.StatusCode, Message: string(body)}
+	}
+
+	raw := struct{ HostConfig *HostConfigExtras }{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if raw.HostConfig == nil {
+		raw.HostConfig = &HostConfigExtras{}
+	}
+
+	return raw.HostConfig, nil
+}
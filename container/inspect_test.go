@@ -0,0 +1,211 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeInspector returns inspect info with the pid given per container and counts inspections
+type fakeInspector struct {
+	pids   map[string]int
+	calls  map[string]int
+	health string
+}
+
+func (f *fakeInspector) inspect(id string) (*docker.Container, error) {
+	f.calls[id]++
+	pid, ok := f.pids[id]
+	if !ok {
+		return nil, errors.New("No such container: " + id)
+	}
+	state := docker.State{Running: true, Pid: pid, Health: docker.Health{Status: f.health}}
+	return &docker.Container{ID: id, State: state}, nil
+}
+
+func (f *fakeInspector) inspectExtras(id string) (*HostConfigExtras, error) {
+	f.calls[id+"/extras"]++
+	return &HostConfigExtras{Runtime: "runc"}, nil
+}
+
+func TestInspectCache(t *testing.T) {
+	Convey("Cache inspect info of containers", t, func() {
+		inspector := &fakeInspector{
+			pids:  map[string]int{"a26c852ce22c": 100, "b37d963df33d": 200, "c48e074e044e": 300},
+			calls: map[string]int{},
+		}
+		now := time.Unix(1500000000, 0)
+		cache := newInspectCache(inspector.inspect, inspector.inspectExtras, time.Minute, 2)
+		cache.now = func() time.Time { return now }
+		cache.sync(map[string]string{"a26c852ce22c": "running", "b37d963df33d": "running", "c48e074e044e": "running"})
+
+		Convey("container is inspected only once while inspect info is valid", func() {
+			for i := 0; i < 3; i++ {
+				info, err := cache.get("a26c852ce22c")
+				So(err, ShouldBeNil)
+				So(info.State.Pid, ShouldEqual, 100)
+			}
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 1)
+			// settings unknown to go-dockerclient are requested only when they are needed, once per inspect info
+			So(inspector.calls, ShouldNotContainKey, "a26c852ce22c/extras")
+			for i := 0; i < 2; i++ {
+				extras, err := cache.getExtras("a26c852ce22c")
+				So(err, ShouldBeNil)
+				So(extras.Runtime, ShouldEqual, "runc")
+			}
+			So(inspector.calls["a26c852ce22c/extras"], ShouldEqual, 1)
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 1)
+		})
+
+		Convey("container is inspected again when inspect info expires", func() {
+			cache.get("a26c852ce22c")
+			inspector.pids["a26c852ce22c"] = 101
+			now = now.Add(time.Minute)
+
+			info, err := cache.get("a26c852ce22c")
+			So(err, ShouldBeNil)
+			So(info.State.Pid, ShouldEqual, 101)
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 2)
+		})
+
+		Convey("container is inspected again on refresh", func() {
+			cache.get("a26c852ce22c")
+			inspector.pids["a26c852ce22c"] = 101
+
+			info, err := cache.refresh("a26c852ce22c")
+			So(err, ShouldBeNil)
+			So(info.State.Pid, ShouldEqual, 101)
+
+			info, err = cache.get("a26c852ce22c")
+			So(err, ShouldBeNil)
+			So(info.State.Pid, ShouldEqual, 101)
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 2)
+		})
+
+		Convey("inspect info is dropped when the container is not listed anymore or its state changes", func() {
+			cache.get("a26c852ce22c")
+			cache.get("b37d963df33d")
+			cache.sync(map[string]string{"a26c852ce22c": "running", "b37d963df33d": "exited"})
+			So(cache.entries, ShouldHaveLength, 1)
+			So(cache.entries, ShouldContainKey, "a26c852ce22c")
+		})
+
+		Convey("inspect info of container with healthcheck expires after the interval of probes", func() {
+			inspector.health = "healthy"
+			cache.get("a26c852ce22c")
+			// inspect info is kept while the state of container does not change
+			cache.sync(map[string]string{"a26c852ce22c": "running"})
+			So(cache.entries, ShouldContainKey, "a26c852ce22c")
+
+			now = now.Add(20 * time.Second)
+			cache.get("a26c852ce22c")
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 1)
+
+			now = now.Add(10 * time.Second)
+			cache.get("a26c852ce22c")
+			So(inspector.calls["a26c852ce22c"], ShouldEqual, 2)
+		})
+
+		Convey("inspect info is dropped on demand", func() {
+			cache.get("a26c852ce22c")
+			cache.drop("a26c852ce22c")
+			So(cache.entries, ShouldBeEmpty)
+		})
+
+		Convey("the oldest inspect info is evicted when the bound is exceeded", func() {
+			cache.get("a26c852ce22c")
+			now = now.Add(time.Second)
+			cache.get("b37d963df33d")
+			now = now.Add(time.Second)
+			cache.get("c48e074e044e")
+
+			So(cache.entries, ShouldHaveLength, 2)
+			So(cache.entries, ShouldNotContainKey, "a26c852ce22c")
+			So(cache.entries, ShouldContainKey, "b37d963df33d")
+			So(cache.entries, ShouldContainKey, "c48e074e044e")
+		})
+
+		Convey("return an error and drop inspect info when the container cannot be inspected", func() {
+			cache.get("a26c852ce22c")
+			delete(inspector.pids, "a26c852ce22c")
+
+			_, err := cache.refresh("a26c852ce22c")
+			So(err, ShouldNotBeNil)
+			So(cache.entries, ShouldBeEmpty)
+		})
+
+		Convey("stale pid of restarted container is replaced by inspecting the container again", func() {
+			cache.get("a26c852ce22c")
+			inspector.pids["a26c852ce22c"] = 101
+
+			// container is restarted while it is running, so its state does not change
+			cache.sync(map[string]string{"a26c852ce22c": "running"})
+			info, _ := cache.get("a26c852ce22c")
+			So(info.State.Pid, ShouldEqual, 100)
+
+			info, err := cache.refresh("a26c852ce22c")
+			So(err, ShouldBeNil)
+			So(info.State.Pid, ShouldEqual, 101)
+			info, _ = cache.get("a26c852ce22c")
+			So(info.State.Pid, ShouldEqual, 101)
+		})
+	})
+}
+
+func TestInspectHostConfigExtras(t *testing.T) {
+	Convey("Request settings of host config unknown to go-dockerclient with docker API", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/containers/a26c852ce22c/json" {
+				http.Error(w, `{"message":"No such container"}`, http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{
+				"Id": "a26c852ce22cbf94f75299b879ccb0d94427aa265778e1e9d6e6483ffb7837ed",
+				"State": {"Running": true, "Pid": 100},
+				"HostConfig": {"Memory": 536870912, "NanoCpus": 1500000000, "Runtime": "runc"}
+			}`))
+		}))
+		defer server.Close()
+
+		cl, err := docker.NewClient(server.URL)
+		So(err, ShouldBeNil)
+		dc := &DockerClient{cl: cl, endpoint: server.URL}
+
+		Convey("settings unknown to go-dockerclient are decoded from inspect info", func() {
+			extras, err := dc.inspectHostConfigExtras("a26c852ce22c")
+			So(err, ShouldBeNil)
+			So(extras, ShouldResemble, &HostConfigExtras{NanoCpus: 1500000000, Runtime: "runc"})
+		})
+
+		Convey("return an error when the container does not exist", func() {
+			_, err := dc.inspectHostConfigExtras("b37d963df33d")
+			So(err, ShouldHaveSameTypeAs, &docker.NoSuchContainer{})
+		})
+	})
+}
@@ -0,0 +1,179 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// containerInventory holds all containers (including not running ones) known from the last full listing,
+// kept up to date by docker events between listings
+type containerInventory struct {
+	mutex      sync.Mutex
+	containers map[string]docker.APIContainers
+	synced     time.Time
+	// containers changed by docker events since the last listing, they are listed together by the next listing
+	changed map[string]struct{}
+}
+
+// listContainers returns containers from the inventory, the inventory is fully listed again when it is older than the resync interval
+// to heal missed events; if docker events are not available, containers are listed directly
+func (dc *DockerClient) listContainers(opts ListOptions, listOpts docker.ListContainersOptions) ([]docker.APIContainers, error) {
+	if opts.ResyncInterval <= 0 {
+		return dc.cl.ListContainers(listOpts)
+	}
+
+	inv := &dc.inventory
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	// subscribe before listing, so no event is lost between listing and subscription
+	if err := dc.subscribeEvents(); err != nil {
+		log.WithFields(log.Fields{
+			"block":    "client",
+			"function": "listContainers",
+		}).Errorf("Unable to subscribe to docker events, containers are listed on each collection: %s", err)
+		return dc.cl.ListContainers(listOpts)
+	}
+
+	if time.Since(inv.synced) >= opts.ResyncInterval {
+		containerList, err := dc.cl.ListContainers(docker.ListContainersOptions{All: true})
+		if err != nil {
+			return nil, err
+		}
+
+		inv.containers = make(map[string]docker.APIContainers, len(containerList))
+		for _, c := range containerList {
+			shortID, err := GetShortID(c.ID)
+			if err != nil {
+				return nil, err
+			}
+			inv.containers[shortID] = c
+		}
+		inv.changed = map[string]struct{}{}
+		inv.synced = time.Now()
+	} else if len(inv.changed) > 0 {
+		if err := dc.listChangedContainers(); err != nil {
+			return nil, err
+		}
+	}
+
+	return filterContainers(inv.containers, opts), nil
+}
+
+// listChangedContainers lists containers changed by docker events since the last listing with one request,
+// changed containers which are not listed anymore are removed from the inventory; must be called with the mutex locked
+func (dc *DockerClient) listChangedContainers() error {
+	inv := &dc.inventory
+	ids := make([]string, 0, len(inv.changed))
+	for id := range inv.changed {
+		ids = append(ids, id)
+	}
+
+	containerList, err := dc.cl.ListContainers(docker.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"id": ids},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		delete(inv.containers, id)
+	}
+	for _, c := range containerList {
+		shortID, err := GetShortID(c.ID)
+		if err != nil {
+			return err
+		}
+		inv.containers[shortID] = c
+	}
+	inv.changed = map[string]struct{}{}
+
+	return nil
+}
+
+// updateInventoryOnEvent marks the container changed by the event, so it is listed again by the next listing,
+// destroyed container is removed from the inventory; docker API is not requested while events are dispatched
+func (dc *DockerClient) updateInventoryOnEvent(id, action string) {
+	shortID, err := GetShortID(id)
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case "destroy":
+		dc.inventory.mutex.Lock()
+		delete(dc.inventory.containers, shortID)
+		delete(dc.inventory.changed, shortID)
+		dc.inventory.mutex.Unlock()
+	case "create", "start", "restart", "die", "pause", "unpause", "rename", "update":
+		dc.inventory.mutex.Lock()
+		// changes are tracked only when the inventory is used
+		if dc.inventory.containers != nil {
+			dc.inventory.changed[shortID] = struct{}{}
+		}
+		dc.inventory.mutex.Unlock()
+	case "health_status":
+		// only health of the container has changed, it is not listed
+	default:
+		return
+	}
+
+	// inspect info does not describe the container anymore
+	dc.inspectCache.drop(shortID)
+}
+
+// resetInventory makes the next listing list all containers, e.g. when events might have been missed
+func (dc *DockerClient) resetInventory() {
+	dc.inventory.mutex.Lock()
+	defer dc.inventory.mutex.Unlock()
+
+	dc.inventory.synced = time.Time{}
+}
+
+// filterContainers returns containers which would be listed by docker with the given options,
+// as docker does, containers which are not running are listed also when their status is requested explicitly
+func filterContainers(containers map[string]docker.APIContainers, opts ListOptions) []docker.APIContainers {
+	statuses := map[string]bool{}
+	for _, status := range opts.Statuses {
+		statuses[status] = true
+	}
+
+	containerList := make([]docker.APIContainers, 0, len(containers))
+	for _, c := range containers {
+		if len(statuses) > 0 {
+			if !statuses[containerState(c)] {
+				continue
+			}
+		} else if !opts.All && !isRunning(c) {
+			continue
+		}
+		containerList = append(containerList, c)
+	}
+
+	return containerList
+}
@@ -0,0 +1,150 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func listedIDs(containers []docker.APIContainers) []string {
+	ids := []string{}
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestInventory(t *testing.T) {
+	Convey("Track containers by docker events", t, func() {
+		containers := map[string]docker.APIContainers{
+			"running00000": {ID: "running00000", State: "running"},
+			"paused000000": {ID: "paused000000", State: "paused"},
+			"exited000000": {ID: "exited000000", State: "exited"},
+			"old000000000": {ID: "old000000000", Status: "Up 2 hours"},
+		}
+
+		Convey("only running containers are listed by default", func() {
+			So(listedIDs(filterContainers(containers, ListOptions{})), ShouldResemble,
+				[]string{"old000000000", "paused000000", "running00000"})
+		})
+
+		Convey("all containers are listed when requested", func() {
+			So(filterContainers(containers, ListOptions{All: true}), ShouldHaveLength, 4)
+		})
+
+		Convey("containers are limited to the given statuses", func() {
+			So(listedIDs(filterContainers(containers, ListOptions{All: true, Statuses: []string{"exited", "paused"}})), ShouldResemble,
+				[]string{"exited000000", "paused000000"})
+		})
+
+		Convey("containers which are not running are listed when their status is requested explicitly", func() {
+			So(listedIDs(filterContainers(containers, ListOptions{Statuses: []string{"exited"}})), ShouldResemble,
+				[]string{"exited000000"})
+		})
+
+		Convey("state of containers listed by older versions of docker is derived from their status", func() {
+			So(listedIDs(filterContainers(containers, ListOptions{Statuses: []string{"running"}})), ShouldResemble,
+				[]string{"old000000000", "running00000"})
+		})
+
+		Convey("container and action are read from events of both new and old API", func() {
+			id, action := containerEvent(&docker.APIEvents{Type: "container", Action: "start", Actor: docker.APIActor{ID: "running00000"}})
+			So(id, ShouldEqual, "running00000")
+			So(action, ShouldEqual, "start")
+
+			id, action = containerEvent(&docker.APIEvents{ID: "exited000000", Status: "die"})
+			So(id, ShouldEqual, "exited000000")
+			So(action, ShouldEqual, "die")
+		})
+
+		Convey("events of other objects are ignored", func() {
+			id, action := containerEvent(&docker.APIEvents{Type: "network", Action: "connect", Actor: docker.APIActor{ID: "bridge"}})
+			So(id, ShouldBeEmpty)
+			So(action, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestInventoryEvents(t *testing.T) {
+	Convey("Update inventory of containers by docker events", t, func() {
+		requests := []url.Values{}
+		running := map[string]string{"running00000": "running", "paused000000": "running"}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Query())
+			filters := map[string][]string{}
+			json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+			containerList := []docker.APIContainers{}
+			for _, id := range filters["id"] {
+				if state, ok := running[id]; ok {
+					containerList = append(containerList, docker.APIContainers{ID: id, State: state})
+				}
+			}
+			json.NewEncoder(w).Encode(containerList)
+		}))
+		defer server.Close()
+
+		cl, err := docker.NewClient(server.URL)
+		So(err, ShouldBeNil)
+		dc := &DockerClient{cl: cl, endpoint: server.URL, inspectCache: newInspectCache(nil, nil, time.Minute, 10)}
+		dc.inventory.containers = map[string]docker.APIContainers{
+			"running00000": {ID: "running00000", State: "created"},
+			"paused000000": {ID: "paused000000", State: "paused"},
+			"exited000000": {ID: "exited000000", State: "exited"},
+		}
+		dc.inventory.changed = map[string]struct{}{}
+
+		Convey("docker API is not requested while events are dispatched", func() {
+			dc.updateInventoryOnEvent("running00000", "start")
+			dc.updateInventoryOnEvent("paused000000", "unpause")
+			dc.updateInventoryOnEvent("exited000000", "destroy")
+			dc.updateInventoryOnEvent("running00000", "exec_create")
+			So(requests, ShouldBeEmpty)
+			So(dc.inventory.changed, ShouldHaveLength, 2)
+			So(dc.inventory.containers, ShouldNotContainKey, "exited000000")
+
+			Convey("changed containers are listed together by the next listing", func() {
+				So(dc.listChangedContainers(), ShouldBeNil)
+				So(requests, ShouldHaveLength, 1)
+				So(dc.inventory.containers["running00000"].State, ShouldEqual, "running")
+				So(dc.inventory.containers["paused000000"].State, ShouldEqual, "running")
+				So(dc.inventory.changed, ShouldBeEmpty)
+			})
+		})
+
+		Convey("changed container which is not listed anymore is removed", func() {
+			dc.updateInventoryOnEvent("exited000000", "die")
+			So(dc.listChangedContainers(), ShouldBeNil)
+			So(dc.inventory.containers, ShouldNotContainKey, "exited000000")
+			So(dc.inventory.containers, ShouldHaveLength, 2)
+		})
+	})
+}
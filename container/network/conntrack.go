@@ -0,0 +1,133 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Conntrack collects usage of connection tracking table of network namespace from net/stat/nf_conntrack
+// and its limit from sys/net/netfilter/nf_conntrack_max; both files are missing when nf_conntrack module is not loaded
+type Conntrack struct{}
+
+func (ct *Conntrack) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	path, err := statsFilePath(opts, "net/stat/nf_conntrack")
+	if err != nil {
+		return err
+	}
+
+	procfs, err := opts.GetStringValue("procfs")
+	if err != nil {
+		return err
+	}
+
+	conntrack, err := scanConntrackStats(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Debugf("Conntrack stats are not available (nf_conntrack module is not loaded), stats file %s", path)
+			return nil
+		}
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get conntrack stats, stats file %s: %s", path, err)
+		return nil
+	}
+
+	// the limit of conntrack table is global, it is available only in procfs of the host
+	maxPath := filepath.Join(procfs, "sys/net/netfilter/nf_conntrack_max")
+	conntrack.Max, err = readUintFromFile(maxPath, 64)
+	if err != nil {
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get limit of conntrack table, stats file %s: %s", maxPath, err)
+	}
+
+	stats.Connection.Conntrack = conntrack
+
+	return nil
+}
+
+// scanConntrackStats parses file in format of net/stat/nf_conntrack which holds a header line with names of counters
+// and a line of hexadecimal values per CPU; counters are summed up over CPUs except `entries` which holds
+// the number of entries in conntrack table of network namespace (the same as nf_conntrack_count)
+func scanConntrackStats(conntrackStatsFile string) (container.ConntrackStat, error) {
+	var stats container.ConntrackStat
+
+	file, err := os.Open(conntrackStatsFile)
+	if err != nil {
+		return stats, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return stats, err
+		}
+		return stats, fmt.Errorf("missing header in conntrack stats file %s", conntrackStatsFile)
+	}
+	header := strings.Fields(scanner.Text())
+
+	for scanner.Scan() {
+		values := strings.Fields(scanner.Text())
+		if len(values) != len(header) {
+			return container.ConntrackStat{}, fmt.Errorf("invalid format of conntrack stats file %s: %v", conntrackStatsFile, values)
+		}
+
+		for i, name := range header {
+			val, err := strconv.ParseUint(values[i], 16, 64)
+			if err != nil {
+				return container.ConntrackStat{}, fmt.Errorf("cannot parse conntrack stats (%v): %v", err, values)
+			}
+
+			switch name {
+			case "entries":
+				stats.Count = val
+			case "insert_failed":
+				stats.InsertFailed += val
+			case "drop":
+				stats.Drop += val
+			case "early_drop":
+				stats.EarlyDrop += val
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return container.ConntrackStat{}, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+var mockConntrackContent = []byte(`entries  searched found new invalid ignore delete delete_list insert insert_failed drop early_drop icmp_error  expect_new expect_create expect_delete search_restart
+0000002a  00000000 00000000 00000000 00000010 00000200 00000000 00000000 00000000 00000003 00000001 00000000 00000000  00000000 00000000 00000000 00000000
+0000002a  00000000 00000000 00000000 00000000 00000100 00000000 00000000 00000000 0000000a 00000002 00000004 00000000  00000000 00000000 00000000 00000000`)
+
+func TestConntrackStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get conntrack stats from procfs", t, func() {
+
+		Convey("create conntrack stats files for mock pids", func() {
+			for _, pid := range mockPids {
+				dir := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/stat")
+				So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+				So(createFile(dir, "nf_conntrack", mockConntrackContent), ShouldBeNil)
+			}
+
+			dir := filepath.Join(mockProcfsDir, "sys/net/netfilter")
+			So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+			So(createFile(dir, "nf_conntrack_max", []byte("262144\n")), ShouldBeNil)
+		})
+
+		Convey("successful retrieving conntrack stats", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPids[0]), "net/stat/nf_conntrack")
+			stats, err := scanConntrackStats(path)
+			So(err, ShouldBeNil)
+			So(stats.Count, ShouldEqual, 42)
+			// counters are summed up over CPUs
+			So(stats.InsertFailed, ShouldEqual, 13)
+			So(stats.Drop, ShouldEqual, 3)
+			So(stats.EarlyDrop, ShouldEqual, 4)
+		})
+
+		Convey("successful setting conntrack stats with limit of conntrack table", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir}
+			err := (&Conntrack{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Conntrack.Count, ShouldEqual, 42)
+			So(stats.Connection.Conntrack.Max, ShouldEqual, 262144)
+		})
+
+		Convey("successful when nf_conntrack module is not loaded", func() {
+			stats := container.NewStatistics()
+			// there is no conntrack stats file for the host
+			opts := container.GetStatOpt{"pid": -1, "is_host": true, "procfs": mockProcfsDir}
+			err := (&Conntrack{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Conntrack, ShouldBeZeroValue)
+		})
+
+		Convey("return an error when the given PID does not exist", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/stat/nf_conntrack")
+			_, err := scanConntrackStats(path)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("return an error when content is invalid", func() {
+			dir := filepath.Join(mockProcfsDir, strconv.Itoa(1), "net/stat")
+			So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+
+			So(createFile(dir, "nf_conntrack", []byte("entries drop\n0000002a")), ShouldBeNil)
+			stats, err := scanConntrackStats(filepath.Join(dir, "nf_conntrack"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeZeroValue)
+
+			So(createFile(dir, "nf_conntrack", []byte("entries drop\n0000002a xyz")), ShouldBeNil)
+			stats, err = scanConntrackStats(filepath.Join(dir, "nf_conntrack"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeZeroValue)
+		})
+	})
+}
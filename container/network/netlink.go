@@ -0,0 +1,344 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+	"golang.org/x/sys/unix"
+)
+
+// rtnetlink constants which are not available in syscall package, see linux/if_link.h,
+// linux/rtnetlink.h, linux/pkt_sched.h and linux/gen_stats.h
+const (
+	iflaLinkNetnsid = 37
+
+	tcaStats  = 3
+	tcaStats2 = 7
+
+	tcaStatsBasic = 1
+	tcaStatsQueue = 3
+	tcaStatsPkt64 = 8
+
+	sizeofTcMsg = 20
+
+	// mask clearing NLA_F_NESTED and NLA_F_NET_BYTEORDER flags from type of attribute
+	nlaTypeMask = 0x3FFF
+
+	netlinkBufferSize = 32 * 1024
+)
+
+// nativeEndian is byte order of netlink messages which are sent in host byte order
+var nativeEndian = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// NetlinkQdiscReader reads statistics of qdiscs and classes over rtnetlink (the same source as `tc -s qdisc` uses)
+type NetlinkQdiscReader struct{}
+
+func (r *NetlinkQdiscReader) Read(netns string) ([]Link, []QdiscEntry, error) {
+	fd, err := openNetlinkSocket(netns)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer syscall.Close(fd)
+
+	links := []Link{}
+	err = netlinkDump(fd, 1, syscall.RTM_GETLINK, make([]byte, syscall.SizeofIfInfomsg), func(msg syscall.NetlinkMessage) error {
+		link, err := parseLinkMessage(msg.Data)
+		if err != nil {
+			return err
+		}
+		links = append(links, link)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot list network interfaces: %v", err)
+	}
+
+	entries := []QdiscEntry{}
+	appendEntry := func(msg syscall.NetlinkMessage) error {
+		entry, err := parseTcMessage(msg.Data)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	}
+
+	err = netlinkDump(fd, 2, syscall.RTM_GETQDISC, make([]byte, sizeofTcMsg), appendEntry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot list qdiscs: %v", err)
+	}
+
+	// classes can be dumped only per network interface
+	for i, link := range links {
+		req := make([]byte, sizeofTcMsg)
+		nativeEndian.PutUint32(req[4:8], uint32(link.Index))
+		err = netlinkDump(fd, uint32(3+i), syscall.RTM_GETTCLASS, req, appendEntry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot list classes of network interface %s: %v", link.Name, err)
+		}
+	}
+
+	return links, entries, nil
+}
+
+// openNetlinkSocket opens rtnetlink socket in the network namespace given by path to its file,
+// socket stays bound to that namespace after the thread which opened it returns to its original namespace
+func openNetlinkSocket(netns string) (int, error) {
+	if netns == "" {
+		return newNetlinkSocket()
+	}
+
+	// network namespace is an attribute of thread, so the goroutine is locked to its thread while the thread is switched,
+	// it is not unlocked when the thread is left in other network namespace
+	runtime.LockOSThread()
+	fd, err := openNetlinkSocketInNetns(netns)
+	runtime.UnlockOSThread()
+
+	return fd, err
+}
+
+// openNetlinkSocketInNetns switches the calling thread to the given network namespace, opens rtnetlink socket there
+// and switches the thread back; thread which cannot return to its original network namespace would be reused by other
+// goroutines (locked thread is not terminated with its goroutine before Go 1.10), so it is a fatal error
+func openNetlinkSocketInNetns(netns string) (int, error) {
+	origin, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid()))
+	if err != nil {
+		return -1, err
+	}
+	defer origin.Close()
+
+	target, err := os.Open(netns)
+	if err != nil {
+		return -1, err
+	}
+	defer target.Close()
+
+	if err := setns(target.Fd()); err != nil {
+		return -1, fmt.Errorf("cannot enter network namespace %s: %v", netns, err)
+	}
+
+	fd, err := newNetlinkSocket()
+	if restoreErr := setns(origin.Fd()); restoreErr != nil {
+		panic(fmt.Sprintf("cannot return to original network namespace from %s: %v", netns, restoreErr))
+	}
+
+	return fd, err
+}
+
+func newNetlinkSocket() (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+
+	return fd, nil
+}
+
+func setns(fd uintptr) error {
+	_, _, errno := syscall.RawSyscall(unix.SYS_SETNS, fd, syscall.CLONE_NEWNET, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// netlinkDump sends dump request of the given type and calls handle for each message of the response
+func netlinkDump(fd int, seq uint32, msgType uint16, payload []byte, handle func(syscall.NetlinkMessage) error) error {
+	req := make([]byte, syscall.NLMSG_HDRLEN+len(payload))
+	nativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	nativeEndian.PutUint16(req[4:6], msgType)
+	nativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	nativeEndian.PutUint32(req[8:12], seq)
+	copy(req[syscall.NLMSG_HDRLEN:], payload)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, netlinkBufferSize)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return err
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if msg.Header.Seq != seq {
+				continue
+			}
+
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) < 4 {
+					return fmt.Errorf("invalid netlink error message")
+				}
+				if errno := int32(nativeEndian.Uint32(msg.Data[0:4])); errno != 0 {
+					return syscall.Errno(-errno)
+				}
+				return nil
+			default:
+				if err := handle(msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// parseLinkMessage returns network interface described by RTM_NEWLINK message (ifinfomsg followed by attributes)
+func parseLinkMessage(data []byte) (Link, error) {
+	if len(data) < syscall.SizeofIfInfomsg {
+		return Link{}, fmt.Errorf("invalid length of link message: %d", len(data))
+	}
+
+	attrs, err := parseAttributes(data[syscall.SizeofIfInfomsg:])
+	if err != nil {
+		return Link{}, err
+	}
+
+	link := Link{
+		Index: int(int32(nativeEndian.Uint32(data[4:8]))),
+		Name:  strings.TrimRight(string(attrs[syscall.IFLA_IFNAME]), "\x00"),
+	}
+
+	// index of linked interface refers to other network namespace only when id of that namespace is given
+	if peer, ok := attrs[syscall.IFLA_LINK]; ok && len(peer) >= 4 {
+		if _, ok := attrs[iflaLinkNetnsid]; ok {
+			link.PeerIndex = int(int32(nativeEndian.Uint32(peer[0:4])))
+		}
+	}
+
+	return link, nil
+}
+
+// parseTcMessage returns statistics of qdisc or class described by RTM_NEWQDISC or RTM_NEWTCLASS message
+// (tcmsg followed by attributes)
+func parseTcMessage(data []byte) (QdiscEntry, error) {
+	if len(data) < sizeofTcMsg {
+		return QdiscEntry{}, fmt.Errorf("invalid length of traffic control message: %d", len(data))
+	}
+
+	attrs, err := parseAttributes(data[sizeofTcMsg:])
+	if err != nil {
+		return QdiscEntry{}, err
+	}
+
+	stats, err := parseQdiscStats(attrs)
+	if err != nil {
+		return QdiscEntry{}, err
+	}
+
+	return QdiscEntry{
+		LinkIndex: int(int32(nativeEndian.Uint32(data[4:8]))),
+		Handle:    nativeEndian.Uint32(data[8:12]),
+		Parent:    nativeEndian.Uint32(data[12:16]),
+		Stats:     stats,
+	}, nil
+}
+
+// parseQdiscStats returns statistics from TCA_STATS2 attribute, older TCA_STATS is used when the former is not available
+func parseQdiscStats(attrs map[uint16][]byte) (container.QdiscStat, error) {
+	stats := container.QdiscStat{}
+
+	if stats2, ok := attrs[tcaStats2]; ok {
+		nested, err := parseAttributes(stats2)
+		if err != nil {
+			return stats, err
+		}
+
+		// struct gnet_stats_basic: bytes (u64), packets (u32)
+		if basic := nested[tcaStatsBasic]; len(basic) >= 12 {
+			stats.Bytes = nativeEndian.Uint64(basic[0:8])
+			stats.Packets = uint64(nativeEndian.Uint32(basic[8:12]))
+		}
+		if packets := nested[tcaStatsPkt64]; len(packets) >= 8 {
+			stats.Packets = nativeEndian.Uint64(packets[0:8])
+		}
+		// struct gnet_stats_queue: qlen, backlog, drops, requeues, overlimits (all u32)
+		if queue := nested[tcaStatsQueue]; len(queue) >= 20 {
+			stats.Backlog = uint64(nativeEndian.Uint32(queue[4:8]))
+			stats.Drops = uint64(nativeEndian.Uint32(queue[8:12]))
+			stats.Requeues = uint64(nativeEndian.Uint32(queue[12:16]))
+			stats.Overlimits = uint64(nativeEndian.Uint32(queue[16:20]))
+		}
+		return stats, nil
+	}
+
+	// struct tc_stats: bytes (u64), packets, drops, overlimits, bps, pps, qlen, backlog (all u32)
+	if old := attrs[tcaStats]; len(old) >= 36 {
+		stats.Bytes = nativeEndian.Uint64(old[0:8])
+		stats.Packets = uint64(nativeEndian.Uint32(old[8:12]))
+		stats.Drops = uint64(nativeEndian.Uint32(old[12:16]))
+		stats.Overlimits = uint64(nativeEndian.Uint32(old[16:20]))
+		stats.Backlog = uint64(nativeEndian.Uint32(old[32:36]))
+	}
+
+	return stats, nil
+}
+
+// parseAttributes returns values of netlink attributes keyed by their type
+func parseAttributes(data []byte) (map[uint16][]byte, error) {
+	attrs := map[uint16][]byte{}
+
+	for len(data) >= syscall.SizeofRtAttr {
+		length := int(nativeEndian.Uint16(data[0:2]))
+		attrType := nativeEndian.Uint16(data[2:4])
+		if length < syscall.SizeofRtAttr || length > len(data) {
+			return nil, fmt.Errorf("invalid length of netlink attribute: %d", length)
+		}
+
+		attrs[attrType&nlaTypeMask] = data[syscall.SizeofRtAttr:length]
+
+		// attributes are aligned to 4 bytes
+		aligned := (length + syscall.RTA_ALIGNTO - 1) & ^(syscall.RTA_ALIGNTO - 1)
+		if aligned > len(data) {
+			break
+		}
+		data = data[aligned:]
+	}
+
+	return attrs, nil
+}
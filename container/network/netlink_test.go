@@ -0,0 +1,137 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mockAttribute returns netlink attribute of the given type, padded to 4 bytes
+func mockAttribute(attrType uint16, value []byte) []byte {
+	length := syscall.SizeofRtAttr + len(value)
+	attr := make([]byte, (length+syscall.RTA_ALIGNTO-1) & ^(syscall.RTA_ALIGNTO-1))
+	nativeEndian.PutUint16(attr[0:2], uint16(length))
+	nativeEndian.PutUint16(attr[2:4], attrType)
+	copy(attr[syscall.SizeofRtAttr:], value)
+	return attr
+}
+
+func mockUint32s(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		nativeEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+func mockTcMessage(ifindex int, handle, parent uint32, attrs ...[]byte) []byte {
+	msg := make([]byte, sizeofTcMsg)
+	nativeEndian.PutUint32(msg[4:8], uint32(ifindex))
+	nativeEndian.PutUint32(msg[8:12], handle)
+	nativeEndian.PutUint32(msg[12:16], parent)
+	for _, attr := range attrs {
+		msg = append(msg, attr...)
+	}
+	return msg
+}
+
+func TestNetlinkMessages(t *testing.T) {
+	Convey("Parse rtnetlink messages", t, func() {
+
+		Convey("successful parsing of link message", func() {
+			msg := make([]byte, syscall.SizeofIfInfomsg)
+			nativeEndian.PutUint32(msg[4:8], 7)
+			msg = append(msg, mockAttribute(syscall.IFLA_IFNAME, []byte("eth0\x00"))...)
+			msg = append(msg, mockAttribute(syscall.IFLA_LINK, mockUint32s(8))...)
+			msg = append(msg, mockAttribute(iflaLinkNetnsid, mockUint32s(0))...)
+
+			link, err := parseLinkMessage(msg)
+			So(err, ShouldBeNil)
+			So(link, ShouldResemble, Link{Index: 7, Name: "eth0", PeerIndex: 8})
+		})
+
+		Convey("linked interface is not a peer when it is in the same network namespace", func() {
+			msg := make([]byte, syscall.SizeofIfInfomsg)
+			nativeEndian.PutUint32(msg[4:8], 3)
+			msg = append(msg, mockAttribute(syscall.IFLA_IFNAME, []byte("macvlan0\x00"))...)
+			msg = append(msg, mockAttribute(syscall.IFLA_LINK, mockUint32s(2))...)
+
+			link, err := parseLinkMessage(msg)
+			So(err, ShouldBeNil)
+			So(link, ShouldResemble, Link{Index: 3, Name: "macvlan0"})
+		})
+
+		Convey("successful parsing of qdisc message with TCA_STATS2", func() {
+			basic := append(make([]byte, 8), mockUint32s(20, 0)...)
+			nativeEndian.PutUint64(basic[0:8], 3000)
+			// qlen, backlog, drops, requeues, overlimits
+			queue := mockUint32s(2, 1500, 4, 1, 9)
+			stats2 := append(mockAttribute(tcaStatsBasic, basic), mockAttribute(tcaStatsQueue, queue)...)
+
+			entry, err := parseTcMessage(mockTcMessage(7, 0x10000, tcHandleRoot,
+				mockAttribute(1, []byte("htb\x00")),
+				mockAttribute(tcaStats2|syscall.NLA_F_NESTED, stats2)))
+			So(err, ShouldBeNil)
+			So(entry.LinkIndex, ShouldEqual, 7)
+			So(entry.Handle, ShouldEqual, 0x10000)
+			So(entry.Parent, ShouldEqual, tcHandleRoot)
+			So(entry.Stats.Bytes, ShouldEqual, 3000)
+			So(entry.Stats.Packets, ShouldEqual, 20)
+			So(entry.Stats.Backlog, ShouldEqual, 1500)
+			So(entry.Stats.Drops, ShouldEqual, 4)
+			So(entry.Stats.Requeues, ShouldEqual, 1)
+			So(entry.Stats.Overlimits, ShouldEqual, 9)
+		})
+
+		Convey("successful parsing of qdisc message with TCA_STATS only", func() {
+			old := append(make([]byte, 8), mockUint32s(20, 4, 9, 0, 0, 2, 1500, 0)...)
+			nativeEndian.PutUint64(old[0:8], 3000)
+
+			entry, err := parseTcMessage(mockTcMessage(2, 0, tcHandleRoot, mockAttribute(tcaStats, old)))
+			So(err, ShouldBeNil)
+			So(entry.Stats.Bytes, ShouldEqual, 3000)
+			So(entry.Stats.Packets, ShouldEqual, 20)
+			So(entry.Stats.Drops, ShouldEqual, 4)
+			So(entry.Stats.Overlimits, ShouldEqual, 9)
+			So(entry.Stats.Backlog, ShouldEqual, 1500)
+		})
+
+		Convey("return an error when message is too short", func() {
+			_, err := parseTcMessage(make([]byte, sizeofTcMsg-1))
+			So(err, ShouldNotBeNil)
+
+			_, err = parseLinkMessage(make([]byte, syscall.SizeofIfInfomsg-1))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when attribute length is invalid", func() {
+			attr := mockAttribute(tcaStats, mockUint32s(1))
+			nativeEndian.PutUint16(attr[0:2], 64)
+
+			_, err := parseTcMessage(mockTcMessage(2, 0, tcHandleRoot, attr))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,200 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+	utils "github.com/intelsdi-x/snap-plugin-utilities/ns"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// state of TCP connection in ESTABLISHED state as listed in net/tcp and net/tcp6
+	tcpStateEstablished = "01"
+
+	// OtherPeers is the name of peer which aggregates connections to peers out of top-N
+	OtherPeers = "other"
+)
+
+// Peers collects the number of established TCP/TCP6 connections aggregated by remote endpoint;
+// it expects in options the following keys:
+// - `peers_top_n` - the number of peers with the most connections which are reported separately (0 means no limit),
+// - `peers_ipv4_prefix` and `peers_ipv6_prefix` - length of prefix used to group remote addresses into networks,
+// - `peers_by_port` - whether remote port distinguishes peers
+type Peers struct{}
+
+func (p *Peers) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	topN, err := opts.GetIntValue("peers_top_n")
+	if err != nil {
+		return err
+	}
+
+	ipv4Prefix, err := opts.GetIntValue("peers_ipv4_prefix")
+	if err != nil {
+		return err
+	}
+
+	ipv6Prefix, err := opts.GetIntValue("peers_ipv6_prefix")
+	if err != nil {
+		return err
+	}
+
+	byPort, err := opts.GetBoolValue("peers_by_port")
+	if err != nil {
+		return err
+	}
+
+	established := map[string]uint64{}
+	for _, statsFile := range []string{"net/tcp", "net/tcp6"} {
+		path, err := statsFilePath(opts, statsFile)
+		if err != nil {
+			return err
+		}
+
+		err = scanTcpSockets(path, func(line string, fields []string) error {
+			if fields[3] != tcpStateEstablished {
+				return nil
+			}
+
+			peer, err := peerName(fields[2], ipv4Prefix, ipv6Prefix, byPort)
+			if err != nil {
+				return fmt.Errorf("invalid TCP stats line (%v): %v", err, line)
+			}
+			established[peer]++
+			return nil
+		})
+		if err != nil {
+			// only log error message
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Errorf("Unable to get connections by remote endpoint, stats file %s: %s", path, err)
+		}
+	}
+
+	stats.Connection.Peers = topPeers(established, topN)
+
+	return nil
+}
+
+// topPeers returns statistics of topN peers with the most established connections,
+// connections to remaining peers are summed up under `other`
+func topPeers(established map[string]uint64, topN int) map[string]container.PeerStat {
+	peers := peersByConnections{names: make([]string, 0, len(established)), established: established}
+	for peer := range established {
+		peers.names = append(peers.names, peer)
+	}
+	sort.Sort(peers)
+
+	stats := map[string]container.PeerStat{}
+	for i, peer := range peers.names {
+		if topN > 0 && i >= topN {
+			other := stats[OtherPeers]
+			other.Established += established[peer]
+			stats[OtherPeers] = other
+			continue
+		}
+		stats[peer] = container.PeerStat{Established: established[peer]}
+	}
+
+	return stats
+}
+
+// peersByConnections sorts peers by the number of established connections in descending order
+type peersByConnections struct {
+	names       []string
+	established map[string]uint64
+}
+
+func (p peersByConnections) Len() int {
+	return len(p.names)
+}
+
+func (p peersByConnections) Swap(i, j int) {
+	p.names[i], p.names[j] = p.names[j], p.names[i]
+}
+
+func (p peersByConnections) Less(i, j int) bool {
+	if p.established[p.names[i]] != p.established[p.names[j]] {
+		return p.established[p.names[i]] > p.established[p.names[j]]
+	}
+	return p.names[i] < p.names[j]
+}
+
+// peerName returns name of remote endpoint, allowed in metric namespace, for the address in format
+// `address:port` as listed in net/tcp or net/tcp6; the address is grouped into network of the given prefix length
+func peerName(address string, ipv4Prefix, ipv6Prefix int, byPort bool) (string, error) {
+	ip, err := parseIP(address)
+	if err != nil {
+		return "", err
+	}
+
+	prefix, bits := ipv6Prefix, 8*net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		// IPv4-mapped IPv6 address is grouped as IPv4 address
+		ip, prefix, bits = ip4, ipv4Prefix, 8*net.IPv4len
+	}
+
+	name := ip.String()
+	if prefix < bits {
+		network := net.IPNet{IP: ip.Mask(net.CIDRMask(prefix, bits)), Mask: net.CIDRMask(prefix, bits)}
+		name = network.String()
+	}
+
+	if byPort {
+		port, err := parsePort(address)
+		if err != nil {
+			return "", err
+		}
+		name = name + ":" + port
+	}
+
+	return utils.ReplaceNotAllowedCharsInNamespacePart(name), nil
+}
+
+// parseIP returns IP from address in format `address:port` as listed in net/tcp or net/tcp6,
+// the address is hexadecimal and each of its 32-bit words is in host byte order (little endian)
+func parseIP(address string) (net.IP, error) {
+	i := strings.LastIndex(address, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid format of address %s", address)
+	}
+
+	ip, err := hex.DecodeString(address[:i])
+	if err != nil {
+		return nil, err
+	}
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return nil, fmt.Errorf("invalid length of address %s", address)
+	}
+
+	for w := 0; w < len(ip); w += 4 {
+		ip[w], ip[w+1], ip[w+2], ip[w+3] = ip[w+3], ip[w+2], ip[w+1], ip[w]
+	}
+
+	return net.IP(ip), nil
+}
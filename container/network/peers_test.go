@@ -0,0 +1,137 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+// remote peers: 10.0.0.1:443 (x3), 10.0.0.1:80, 10.0.0.2:443 (x2), 192.168.1.7:5432 and 192.168.1.7 in state "Time_Wait"
+var mockTcpPeersContent = []byte(`sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+				   0: 0500000A:C350 0100000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118201 1 ffff8806a1817000 20 0 0 10 -1
+				   1: 0500000A:C351 0100000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118202 1 ffff8806a1817400 20 0 0 10 -1
+				   2: 0500000A:C352 0100000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118203 1 ffff8806a1817800 20 0 0 10 -1
+				   3: 0500000A:C353 0100000A:0050 01 00000000:00000000 00:00000000 00000000     0        0 5118204 1 ffff8806a1817c00 20 0 0 10 -1
+				   4: 0500000A:C354 0200000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118205 1 ffff8806a1818000 20 0 0 10 -1
+				   5: 0500000A:C355 0200000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118206 1 ffff8806a1818400 20 0 0 10 -1
+				   6: 0500000A:C356 0701A8C0:1538 01 00000000:00000000 00:00000000 00000000     0        0 5118207 1 ffff8806a1818800 20 0 0 10 -1
+				   7: 0500000A:C357 0701A8C0:1538 06 00000000:00000000 00:00000000 00000000     0        0 5118208 1 ffff8806a1818c00 20 0 0 10 -1`)
+
+// remote peers: 2001:db8::1:443 and IPv4-mapped 10.0.0.5:443
+var mockTcp6PeersContent = []byte(`sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+				   0: 00000000000000000000000001000000:C350 B80D0120000000000000000001000000:01BB 01 00000000:00000000 00:00000000 00000000     0        0 6118201 1 ffff8806a1819000 20 0 0 10 -1
+				   1: 0000000000000000FFFF00000600000A:C351 0000000000000000FFFF00000500000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 6118202 1 ffff8806a1819400 20 0 0 10 -1`)
+
+func TestPeersStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get TCP connections aggregated by remote endpoint from procfs", t, func() {
+
+		Convey("create TCP/TCP6 statistics for mock pids", func() {
+			err := createMockProcfsNetTCP(mockPids, mockTcpPeersContent)
+			So(err, ShouldBeNil)
+			for _, pid := range mockPids {
+				err = createFile(filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net"), "tcp6", mockTcp6PeersContent)
+				So(err, ShouldBeNil)
+			}
+		})
+
+		opts := container.GetStatOpt{
+			"pid":               mockPids[0],
+			"is_host":           false,
+			"procfs":            mockProcfsDir,
+			"peers_top_n":       0,
+			"peers_ipv4_prefix": 32,
+			"peers_ipv6_prefix": 128,
+			"peers_by_port":     false,
+		}
+
+		Convey("successful aggregating connections by remote address", func() {
+			stats := container.NewStatistics()
+			err := (&Peers{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Peers, ShouldResemble, map[string]container.PeerStat{
+				"10_0_0_1":    {Established: 4},
+				"10_0_0_2":    {Established: 2},
+				"10_0_0_5":    {Established: 1},
+				"192_168_1_7": {Established: 1},
+				"2001:db8::1": {Established: 1},
+			})
+		})
+
+		Convey("successful aggregating connections by remote network", func() {
+			opts["peers_ipv4_prefix"] = 24
+			opts["peers_ipv6_prefix"] = 32
+			stats := container.NewStatistics()
+			err := (&Peers{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Peers, ShouldResemble, map[string]container.PeerStat{
+				"10_0_0_0_24":    {Established: 7},
+				"192_168_1_0_24": {Established: 1},
+				"2001:db8::_32":  {Established: 1},
+			})
+		})
+
+		Convey("successful aggregating connections by remote address and port", func() {
+			opts["peers_by_port"] = true
+			stats := container.NewStatistics()
+			err := (&Peers{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Peers["10_0_0_1:443"].Established, ShouldEqual, 3)
+			So(stats.Connection.Peers["10_0_0_1:80"].Established, ShouldEqual, 1)
+			So(stats.Connection.Peers["192_168_1_7:5432"].Established, ShouldEqual, 1)
+		})
+
+		Convey("successful limiting the number of peers", func() {
+			opts["peers_top_n"] = 2
+			stats := container.NewStatistics()
+			err := (&Peers{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Peers, ShouldResemble, map[string]container.PeerStat{
+				"10_0_0_1": {Established: 4},
+				"10_0_0_2": {Established: 2},
+				OtherPeers: {Established: 3},
+			})
+		})
+
+		Convey("return an error when configuration is not passed", func() {
+			stats := container.NewStatistics()
+			err := (&Peers{}).GetStats(stats, container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when remote address is invalid", func() {
+			_, err := peerName("0100000A", 32, 128, false)
+			So(err, ShouldNotBeNil)
+			_, err = peerName("01000A:01BB", 32, 128, false)
+			So(err, ShouldNotBeNil)
+			_, err = peerName("0100000X:01BB", 32, 128, false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,191 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// state of TCP socket in LISTEN state as listed in net/tcp and net/tcp6
+	tcpStateListen = "0A"
+	// state of UDP socket which is not connected to a remote peer as listed in net/udp and net/udp6
+	udpStateUnconnected = "07"
+)
+
+// listeningSockets describes where to look for listening sockets of a given protocol
+var listeningSockets = []struct {
+	statsFile string
+	proto     string
+	state     string
+}{
+	{"net/tcp", "tcp", tcpStateListen},
+	{"net/tcp6", "tcp", tcpStateListen},
+	{"net/udp", "udp", udpStateUnconnected},
+	{"net/udp6", "udp", udpStateUnconnected},
+}
+
+// Ports collects inventory of listening ports and compares it with ports published by the container,
+// published ports are expected in options under the key `published_ports` (in format `<port>_<proto>`, e.g. 80_tcp)
+type Ports struct{}
+
+func (p *Ports) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	published, err := getPublishedPorts(opts)
+	if err != nil {
+		return err
+	}
+
+	listening := map[string]struct{}{}
+	for _, ls := range listeningSockets {
+		path, err := statsFilePath(opts, ls.statsFile)
+		if err != nil {
+			return err
+		}
+
+		ports, err := scanListeningPorts(path, ls.proto, ls.state)
+		if err != nil {
+			// only log error message
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Errorf("Unable to get listening ports, stats file %s: %s", path, err)
+			continue
+		}
+
+		for _, port := range ports {
+			listening[port] = struct{}{}
+		}
+	}
+
+	stats.Connection.Ports = newPortsStat(listening, published)
+
+	return nil
+}
+
+// getPublishedPorts returns set of published ports passed in options
+func getPublishedPorts(opts container.GetStatOpt) (map[string]struct{}, error) {
+	published := map[string]struct{}{}
+
+	val, exists := opts["published_ports"]
+	if !exists {
+		return published, nil
+	}
+
+	ports, ok := val.([]string)
+	if !ok {
+		return nil, fmt.Errorf("value %v seems not be of string slice type", val)
+	}
+
+	for _, port := range ports {
+		published[port] = struct{}{}
+	}
+
+	return published, nil
+}
+
+// newPortsStat returns summary of listening and published ports
+func newPortsStat(listening, published map[string]struct{}) container.PortsStat {
+	stats := container.PortsStat{
+		Listening: uint64(len(listening)),
+		Published: uint64(len(published)),
+		PerPort:   map[string]container.PortStat{},
+	}
+
+	for port := range listening {
+		portStat := container.PortStat{Listening: 1}
+		if _, ok := published[port]; ok {
+			portStat.Published = 1
+		} else {
+			stats.Unpublished++
+		}
+		stats.PerPort[port] = portStat
+	}
+
+	for port := range published {
+		if _, ok := listening[port]; !ok {
+			stats.NotListening++
+			stats.PerPort[port] = container.PortStat{Published: 1}
+		}
+	}
+
+	return stats
+}
+
+// scanListeningPorts returns local ports (in format `<port>_<proto>`) of sockets in the given state
+// listed in the file in format of net/tcp (net/tcp6, net/udp and net/udp6 have the same format)
+func scanListeningPorts(statsFile, proto, state string) ([]string, error) {
+	file, err := os.Open(statsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failure opening %s: %v", statsFile, err)
+	}
+	defer file.Close()
+
+	ports := []string{}
+	scanner := bufio.NewScanner(file)
+
+	// Discard header line
+	if b := scanner.Scan(); !b {
+		return ports, scanner.Err()
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		// Format: sl local_address rem_address st tx_queue:rx_queue ...
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("invalid format of stats file %s: %v", statsFile, line)
+		}
+
+		if fields[3] != state {
+			continue
+		}
+
+		if state == udpStateUnconnected {
+			// skip sockets connected to a remote peer
+			remotePort, err := parsePort(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid stats line (%v): %v", err, line)
+			}
+			if remotePort != "0" {
+				continue
+			}
+		}
+
+		port, err := parsePort(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid stats line (%v): %v", err, line)
+		}
+
+		ports = append(ports, port+"_"+proto)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ports, nil
+}
@@ -0,0 +1,106 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+func TestPortsStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get listening ports from procfs", t, func() {
+
+		Convey("create socket stats files for mock pids", func() {
+			err := createMockProcfsNetTCP(mockPids, mockTcpQueuesContent)
+			So(err, ShouldBeNil)
+			err = createMockProcfsNetSockets(mockPids, mockUdpContent, mockRawContent, mockUnixContent)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("successful retrieving listening TCP ports", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPids[0]), "net/tcp")
+			ports, err := scanListeningPorts(path, "tcp", tcpStateListen)
+			So(err, ShouldBeNil)
+			// sockets listening on the same port are reported for each local address
+			So(ports, ShouldResemble, []string{"8080_tcp", "8080_tcp", "22_tcp"})
+		})
+
+		Convey("successful retrieving listening UDP ports", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPids[0]), "net/udp")
+			ports, err := scanListeningPorts(path, "udp", udpStateUnconnected)
+			So(err, ShouldBeNil)
+			// connected UDP socket is skipped
+			So(ports, ShouldResemble, []string{"53_udp", "68_udp"})
+		})
+
+		Convey("successful comparing listening ports with published ports", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{
+				"pid":             mockPids[0],
+				"is_host":         false,
+				"procfs":          mockProcfsDir,
+				"published_ports": []string{"8080_tcp", "443_tcp"},
+			}
+			err := (&Ports{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+
+			ports := stats.Connection.Ports
+			So(ports.Listening, ShouldEqual, 4)
+			So(ports.Published, ShouldEqual, 2)
+			So(ports.Unpublished, ShouldEqual, 3)
+			So(ports.NotListening, ShouldEqual, 1)
+			So(ports.PerPort["8080_tcp"], ShouldResemble, container.PortStat{Listening: 1, Published: 1})
+			So(ports.PerPort["22_tcp"], ShouldResemble, container.PortStat{Listening: 1, Published: 0})
+			So(ports.PerPort["443_tcp"], ShouldResemble, container.PortStat{Listening: 0, Published: 1})
+		})
+
+		Convey("successful when published ports are not passed", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir}
+			err := (&Ports{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Ports.Published, ShouldEqual, 0)
+			So(stats.Connection.Ports.Unpublished, ShouldEqual, stats.Connection.Ports.Listening)
+		})
+
+		Convey("return an error when published ports are of invalid type", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir, "published_ports": "80_tcp"}
+			err := (&Ports{}).GetStats(stats, opts)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when the given PID does not exist", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/tcp")
+			ports, err := scanListeningPorts(path, "tcp", tcpStateListen)
+			So(err, ShouldNotBeNil)
+			So(ports, ShouldBeNil)
+		})
+	})
+}
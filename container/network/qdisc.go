@@ -0,0 +1,210 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// parent of qdisc attached directly to network interface (TC_H_ROOT)
+const tcHandleRoot = 0xFFFFFFFF
+
+// QdiscReader reads statistics of traffic control qdiscs and classes in a network namespace
+type QdiscReader interface {
+	// Read returns network interfaces and statistics of their qdiscs and classes in the network namespace
+	// given by path to its file (e.g. /proc/<pid>/ns/net), empty path means the current network namespace
+	Read(netns string) ([]Link, []QdiscEntry, error)
+}
+
+// Link describes network interface
+type Link struct {
+	Index int
+	Name  string
+	// PeerIndex is index of interface in other network namespace linked to this one (e.g. peer of veth pair), 0 if there is none
+	PeerIndex int
+}
+
+// QdiscEntry holds statistics of qdisc or class attached to network interface
+type QdiscEntry struct {
+	LinkIndex int
+	Handle    uint32
+	Parent    uint32
+	Stats     container.QdiscStat
+}
+
+// Qdisc collects statistics of qdiscs and classes per network interface, for containers also qdiscs
+// on the host side of veth pairs are taken into account as they shape traffic of the container as well
+type Qdisc struct {
+	Reader QdiscReader
+
+	mutex sync.Mutex
+	host  *hostQdiscs // the last dump of host network namespace, shared by all containers of one collection
+}
+
+// hostQdiscs holds network interfaces and qdiscs of host network namespace indexed by interface
+type hostQdiscs struct {
+	collection time.Time
+	links      map[int]Link
+	entries    map[int][]QdiscEntry
+	err        error
+}
+
+func (q *Qdisc) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	pid, err := opts.GetIntValue("pid")
+	if err != nil {
+		return err
+	}
+
+	isHost, err := opts.GetBoolValue("is_host")
+	if err != nil {
+		return err
+	}
+
+	procfs, err := opts.GetStringValue("procfs")
+	if err != nil {
+		return err
+	}
+
+	netns := ""
+	if !isHost {
+		netns = filepath.Join(procfs, strconv.Itoa(pid), "ns/net")
+	}
+
+	// host network namespace is dumped only once per collection (given by its start time),
+	// it is dumped on each call when collection is not given
+	collection, _ := opts["collection"].(time.Time)
+
+	if isHost {
+		host := q.readHost(collection)
+		if host.err != nil {
+			return fmt.Errorf("Unable to get qdisc statistics in network namespace of host: %v", host.err)
+		}
+		links := []Link{}
+		entries := []QdiscEntry{}
+		for index, link := range host.links {
+			links = append(links, link)
+			entries = append(entries, host.entries[index]...)
+		}
+		stats.Qdisc = qdiscStatsByLink(links, entries)
+		return nil
+	}
+
+	links, entries, err := q.Reader.Read(netns)
+	if err != nil {
+		return fmt.Errorf("Unable to get qdisc statistics in network namespace %s: %v", netns, err)
+	}
+	qdiscs := qdiscStatsByLink(links, entries)
+
+	peerLinks := []Link{}
+	peerEntries := []QdiscEntry{}
+	for _, link := range links {
+		if link.PeerIndex <= 0 {
+			continue
+		}
+		host := q.readHost(collection)
+		if host.err != nil {
+			// only log error message
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Errorf("Unable to get qdisc statistics on the host side of container interfaces: %s", host.err)
+			break
+		}
+		if peer, ok := host.links[link.PeerIndex]; ok {
+			peerLinks = append(peerLinks, peer)
+			peerEntries = append(peerEntries, host.entries[peer.Index]...)
+		}
+	}
+	for name, handles := range qdiscStatsByLink(peerLinks, peerEntries) {
+		qdiscs[name] = handles
+	}
+
+	stats.Qdisc = qdiscs
+
+	return nil
+}
+
+// readHost returns dump of host network namespace taken during the given collection, the dump is taken when there is none yet
+func (q *Qdisc) readHost(collection time.Time) *hostQdiscs {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.host != nil && !collection.IsZero() && q.host.collection.Equal(collection) {
+		return q.host
+	}
+
+	host := &hostQdiscs{collection: collection, links: map[int]Link{}, entries: map[int][]QdiscEntry{}}
+	links, entries, err := q.Reader.Read("")
+	if err != nil {
+		host.err = err
+	}
+	for _, link := range links {
+		host.links[link.Index] = link
+	}
+	for _, entry := range entries {
+		host.entries[entry.LinkIndex] = append(host.entries[entry.LinkIndex], entry)
+	}
+
+	q.host = host
+	return host
+}
+
+// qdiscStatsByLink returns statistics of qdiscs and classes attached to the given links, keyed by name of link and handle
+func qdiscStatsByLink(links []Link, entries []QdiscEntry) map[string]map[string]container.QdiscStat {
+	names := map[int]string{}
+	for _, link := range links {
+		names[link.Index] = link.Name
+	}
+
+	qdiscs := map[string]map[string]container.QdiscStat{}
+	for _, entry := range entries {
+		name, ok := names[entry.LinkIndex]
+		if !ok {
+			continue
+		}
+		if _, ok := qdiscs[name]; !ok {
+			qdiscs[name] = map[string]container.QdiscStat{}
+		}
+		qdiscs[name][qdiscHandleName(entry.Handle, entry.Parent)] = entry.Stats
+	}
+
+	return qdiscs
+}
+
+// qdiscHandleName returns handle in format `<major>:<minor>` (hexadecimal numbers as presented by tc),
+// qdiscs created by kernel without handle (e.g. children of mq) are distinguished by their parent, e.g. `parent_0:1`
+func qdiscHandleName(handle, parent uint32) string {
+	if handle == 0 && parent != tcHandleRoot {
+		return "parent_" + formatTcHandle(parent)
+	}
+	return formatTcHandle(handle)
+}
+
+func formatTcHandle(handle uint32) string {
+	return fmt.Sprintf("%x:%x", handle>>16, handle&0xFFFF)
+}
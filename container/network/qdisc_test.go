@@ -0,0 +1,178 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+// fakeQdiscReader returns predefined interfaces and qdiscs per network namespace
+type fakeQdiscReader struct {
+	links   map[string][]Link
+	entries map[string][]QdiscEntry
+	errs    map[string]error
+	reads   []string
+}
+
+func (f *fakeQdiscReader) Read(netns string) ([]Link, []QdiscEntry, error) {
+	f.reads = append(f.reads, netns)
+	if err := f.errs[netns]; err != nil {
+		return nil, nil, err
+	}
+	return f.links[netns], f.entries[netns], nil
+}
+
+func newFakeQdiscReader(containerNetns string) *fakeQdiscReader {
+	return &fakeQdiscReader{
+		links: map[string][]Link{
+			containerNetns: {
+				{Index: 1, Name: "lo"},
+				{Index: 7, Name: "eth0", PeerIndex: 8},
+			},
+			"": {
+				{Index: 1, Name: "lo"},
+				{Index: 2, Name: "eth0"},
+				{Index: 8, Name: "veth1a2b3c"},
+				{Index: 9, Name: "veth4d5e6f"},
+			},
+		},
+		entries: map[string][]QdiscEntry{
+			containerNetns: {
+				{LinkIndex: 1, Handle: 0, Parent: tcHandleRoot},
+				{LinkIndex: 7, Handle: 0x10000, Parent: tcHandleRoot, Stats: container.QdiscStat{Bytes: 1111, Drops: 11, Overlimits: 5}},
+				{LinkIndex: 7, Handle: 0x10010, Parent: 0x10000, Stats: container.QdiscStat{Bytes: 2222, Drops: 22, Backlog: 300}},
+			},
+			"": {
+				{LinkIndex: 2, Handle: 0, Parent: tcHandleRoot, Stats: container.QdiscStat{Bytes: 9999}},
+				{LinkIndex: 2, Handle: 0, Parent: 0x1, Stats: container.QdiscStat{Bytes: 4444}},
+				{LinkIndex: 8, Handle: 0x80010000, Parent: tcHandleRoot, Stats: container.QdiscStat{Bytes: 3333, Drops: 33, Requeues: 1}},
+				{LinkIndex: 9, Handle: 0, Parent: tcHandleRoot, Stats: container.QdiscStat{Bytes: 5555}},
+			},
+		},
+		errs: map[string]error{},
+	}
+}
+
+func TestQdiscStats(t *testing.T) {
+	Convey("Get qdisc statistics", t, func() {
+		mockPid := mockPids[0]
+		containerNetns := filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "ns/net")
+
+		Convey("successful setting qdisc statistics for a container including the host side of veth pair", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPid, "is_host": false, "procfs": mockProcfsDir}
+
+			err := (&Qdisc{Reader: reader}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(reader.reads, ShouldResemble, []string{containerNetns, ""})
+			So(len(stats.Qdisc), ShouldEqual, 3)
+			So(stats.Qdisc["lo"], ShouldContainKey, "0:0")
+			So(stats.Qdisc["eth0"]["1:0"].Overlimits, ShouldEqual, 5)
+			So(stats.Qdisc["eth0"]["1:10"].Backlog, ShouldEqual, 300)
+			So(stats.Qdisc["veth1a2b3c"]["8001:0"].Drops, ShouldEqual, 33)
+			So(stats.Qdisc, ShouldNotContainKey, "veth4d5e6f")
+		})
+
+		Convey("successful setting qdisc statistics for the host", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": 1, "is_host": true, "procfs": mockProcfsDir}
+
+			err := (&Qdisc{Reader: reader}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(reader.reads, ShouldResemble, []string{""})
+			So(len(stats.Qdisc), ShouldEqual, 3)
+			So(stats.Qdisc["eth0"]["0:0"].Bytes, ShouldEqual, 9999)
+			So(stats.Qdisc["eth0"]["parent_0:1"].Bytes, ShouldEqual, 4444)
+			So(stats.Qdisc["veth4d5e6f"]["0:0"].Bytes, ShouldEqual, 5555)
+		})
+
+		Convey("successful reading the host only once per collection", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			qdisc := &Qdisc{Reader: reader}
+			collection := time.Now()
+
+			err := qdisc.GetStats(container.NewStatistics(), container.GetStatOpt{"pid": mockPid, "is_host": false, "procfs": mockProcfsDir, "collection": collection})
+			So(err, ShouldBeNil)
+			stats := container.NewStatistics()
+			err = qdisc.GetStats(stats, container.GetStatOpt{"pid": 1, "is_host": true, "procfs": mockProcfsDir, "collection": collection})
+			So(err, ShouldBeNil)
+			So(reader.reads, ShouldResemble, []string{containerNetns, ""})
+			So(stats.Qdisc["eth0"]["0:0"].Bytes, ShouldEqual, 9999)
+
+			// the host is read again by the next collection
+			err = qdisc.GetStats(container.NewStatistics(), container.GetStatOpt{"pid": mockPid, "is_host": false, "procfs": mockProcfsDir, "collection": collection.Add(time.Second)})
+			So(err, ShouldBeNil)
+			So(reader.reads, ShouldResemble, []string{containerNetns, "", containerNetns, ""})
+		})
+
+		Convey("successful setting qdisc statistics of a container when the host side cannot be read", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			reader.errs[""] = errors.New("permission denied")
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPid, "is_host": false, "procfs": mockProcfsDir}
+
+			err := (&Qdisc{Reader: reader}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(len(stats.Qdisc), ShouldEqual, 2)
+			So(stats.Qdisc["eth0"]["1:0"].Bytes, ShouldEqual, 1111)
+		})
+
+		Convey("return an error when network namespace of a container cannot be read", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			reader.errs[containerNetns] = errors.New("no such file or directory")
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPid, "is_host": false, "procfs": mockProcfsDir}
+
+			err := (&Qdisc{Reader: reader}).GetStats(stats, opts)
+			So(err, ShouldNotBeNil)
+			So(stats.Qdisc, ShouldBeEmpty)
+		})
+
+		Convey("return an error when options are invalid", func() {
+			reader := newFakeQdiscReader(containerNetns)
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": "invalid", "is_host": false, "procfs": mockProcfsDir}
+
+			err := (&Qdisc{Reader: reader}).GetStats(stats, opts)
+			So(err, ShouldNotBeNil)
+			So(reader.reads, ShouldBeEmpty)
+		})
+
+		Convey("format handles as presented by tc", func() {
+			So(qdiscHandleName(0x10000, tcHandleRoot), ShouldEqual, "1:0")
+			So(qdiscHandleName(0x1000a, 0x10000), ShouldEqual, "1:a")
+			So(qdiscHandleName(0xffff0000, 0xfffffff1), ShouldEqual, "ffff:0")
+			So(qdiscHandleName(0, tcHandleRoot), ShouldEqual, "0:0")
+			So(qdiscHandleName(0, 0x2), ShouldEqual, "parent_0:2")
+		})
+	})
+}
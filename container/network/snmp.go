@@ -0,0 +1,184 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// snmpProtocols maps protocol names used in net/snmp and net/netstat to the names exposed in metrics namespace
+	snmpProtocols = map[string]string{
+		"Ip":      "ip",
+		"Icmp":    "icmp",
+		"IcmpMsg": "icmp_msg",
+		"Tcp":     "tcp",
+		"Udp":     "udp",
+		"UdpLite": "udp_lite",
+		"TcpExt":  "tcp_ext",
+		"IpExt":   "ip_ext",
+	}
+
+	// snmp6Protocols maps prefixes of counters in net/snmp6 to the names exposed in metrics namespace,
+	// the longest prefixes go first so that e.g. `UdpLite6` is not taken as `Udp6`
+	snmp6Protocols = [][2]string{
+		{"UdpLite6", "udp_lite6"},
+		{"Icmp6", "icmp6"},
+		{"Udp6", "udp6"},
+		{"Ip6", "ip6"},
+	}
+)
+
+// Snmp collects protocol counters (e.g. TCP retransmits, UDP receive buffer errors)
+// from net/snmp, net/netstat and net/snmp6
+type Snmp struct{}
+
+func (s *Snmp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	readers := map[string]func(string) (map[string]map[string]uint64, error){
+		"net/snmp":    scanSnmpStats,
+		"net/netstat": scanSnmpStats,
+		"net/snmp6":   scanSnmp6Stats,
+	}
+
+	for statsFile, read := range readers {
+		path, err := statsFilePath(opts, statsFile)
+		if err != nil {
+			return err
+		}
+
+		counters, err := read(path)
+		if err != nil {
+			// only log error message
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Errorf("Unable to get protocol counters, stats file %s: %s", path, err)
+			continue
+		}
+
+		for proto, values := range counters {
+			stats.Connection.Snmp[proto] = values
+		}
+	}
+
+	return nil
+}
+
+// scanSnmpStats parses file in format of net/snmp and net/netstat where each protocol is described
+// by a pair of lines: the first one holds names of counters (e.g. `Tcp: RtoAlgorithm RtoMin ...`),
+// the second one their values (e.g. `Tcp: 1 200 ...`)
+func scanSnmpStats(snmpStatsFile string) (map[string]map[string]uint64, error) {
+	file, err := os.Open(snmpStatsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failure opening %s: %v", snmpStatsFile, err)
+	}
+	defer file.Close()
+
+	stats := map[string]map[string]uint64{}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		header := strings.Fields(scanner.Text())
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("invalid format of protocol stats file %s: missing values for %v", snmpStatsFile, header)
+		}
+		values := strings.Fields(scanner.Text())
+
+		if len(header) == 0 || len(header) != len(values) || header[0] != values[0] {
+			return nil, fmt.Errorf("invalid format of protocol stats file %s: %v", snmpStatsFile, values)
+		}
+
+		proto, ok := snmpProtocols[strings.TrimSuffix(header[0], ":")]
+		if !ok {
+			// skip protocols which are not supported
+			continue
+		}
+
+		counters := map[string]uint64{}
+		for i := 1; i < len(header); i++ {
+			val, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				// skip values which are not counters (e.g. Tcp MaxConn equals -1)
+				continue
+			}
+			counters[header[i]] = val
+		}
+		stats[proto] = counters
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// scanSnmp6Stats parses file in format of net/snmp6 where each line holds counter name prefixed
+// with protocol name and its value (e.g. `Ip6InReceives 1234`)
+func scanSnmp6Stats(snmp6StatsFile string) (map[string]map[string]uint64, error) {
+	file, err := os.Open(snmp6StatsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failure opening %s: %v", snmp6StatsFile, err)
+	}
+	defer file.Close()
+
+	stats := map[string]map[string]uint64{}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid format of protocol stats file %s: %v", snmp6StatsFile, fields)
+		}
+
+		for _, p := range snmp6Protocols {
+			prefix, proto := p[0], p[1]
+			if !strings.HasPrefix(fields[0], prefix) {
+				continue
+			}
+
+			val, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse protocol stats (%v): %v", err, fields)
+			}
+
+			if _, exists := stats[proto]; !exists {
+				stats[proto] = map[string]uint64{}
+			}
+			stats[proto][strings.TrimPrefix(fields[0], prefix)] = val
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
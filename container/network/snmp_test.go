@@ -0,0 +1,168 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+var mockSnmpContent = []byte(`Ip: Forwarding DefaultTTL InReceives InHdrErrors
+Ip: 1 64 2464 0
+Icmp: InMsgs InErrors InCsumErrors
+Icmp: 10 2 0
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens RetransSegs InErrs OutRsts
+Tcp: 1 200 120000 -1 31 7 3 12
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors
+Udp: 120 4 5 118 5 0`)
+
+var mockNetstatContent = []byte(`TcpExt: SyncookiesSent ListenOverflows ListenDrops TCPTimeouts
+TcpExt: 0 11 13 2
+IpExt: InNoRoutes InOctets OutOctets
+IpExt: 0 123456 654321
+MPTcpExt: MPCapableSYNRX
+MPTcpExt: 0`)
+
+var mockSnmp6Content = []byte(`Ip6InReceives                   	54
+Ip6InHdrErrors                  	1
+Icmp6InMsgs                     	8
+Icmp6InErrors                   	2
+Udp6InDatagrams                 	30
+Udp6RcvbufErrors                	3
+UdpLite6InDatagrams             	0`)
+
+func TestSnmpStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get protocol counters from procfs", t, func() {
+
+		Convey("create protocol stats files for mock pids", func() {
+			err := createMockProcfsNetSnmp(mockPids, mockSnmpContent, mockNetstatContent, mockSnmp6Content)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("successful retrieving counters from net/snmp", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/snmp")
+				stats, err := scanSnmpStats(path)
+				So(err, ShouldBeNil)
+				So(stats, ShouldContainKey, "ip")
+				So(stats, ShouldContainKey, "icmp")
+				So(stats["tcp"]["RetransSegs"], ShouldEqual, 7)
+				So(stats["tcp"]["OutRsts"], ShouldEqual, 12)
+				So(stats["udp"]["RcvbufErrors"], ShouldEqual, 5)
+				So(stats["icmp"]["InErrors"], ShouldEqual, 2)
+				// values which are not counters are skipped
+				So(stats["tcp"], ShouldNotContainKey, "MaxConn")
+			}
+		})
+
+		Convey("successful retrieving counters from net/netstat", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/netstat")
+				stats, err := scanSnmpStats(path)
+				So(err, ShouldBeNil)
+				So(stats["tcp_ext"]["ListenOverflows"], ShouldEqual, 11)
+				So(stats["tcp_ext"]["ListenDrops"], ShouldEqual, 13)
+				So(stats["ip_ext"]["InOctets"], ShouldEqual, 123456)
+				// protocols which are not supported are skipped
+				So(len(stats), ShouldEqual, 2)
+			}
+		})
+
+		Convey("successful retrieving counters from net/snmp6", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/snmp6")
+				stats, err := scanSnmp6Stats(path)
+				So(err, ShouldBeNil)
+				So(stats["ip6"]["InReceives"], ShouldEqual, 54)
+				So(stats["icmp6"]["InErrors"], ShouldEqual, 2)
+				So(stats["udp6"]["RcvbufErrors"], ShouldEqual, 3)
+				So(stats["udp_lite6"], ShouldContainKey, "InDatagrams")
+				So(stats["udp6"], ShouldNotContainKey, "InDatagrams6")
+			}
+		})
+
+		Convey("successful setting protocol counters in statistics", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir}
+			err := (&Snmp{}).GetStats(stats, opts)
+			So(err, ShouldBeNil)
+			So(stats.Connection.Snmp["tcp"]["RetransSegs"], ShouldEqual, 7)
+			So(stats.Connection.Snmp["tcp_ext"]["ListenDrops"], ShouldEqual, 13)
+			So(stats.Connection.Snmp["udp6"]["RcvbufErrors"], ShouldEqual, 3)
+		})
+
+		Convey("return an error when the given PID does not exist", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/snmp")
+			stats, err := scanSnmpStats(path)
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+		})
+
+		Convey("return an error when content is invalid", func() {
+			mockPid := 1
+			err := createMockProcfsNetSnmp([]int{mockPid}, []byte(`Tcp: RtoAlgorithm RtoMin`), []byte(`invalid`), []byte(`Ip6InReceives`))
+			So(err, ShouldBeNil)
+
+			stats, err := scanSnmpStats(filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "net/snmp"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+
+			stats, err = scanSnmp6Stats(filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "net/snmp6"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+		})
+	})
+}
+
+// createMockProcfsNetSnmp creates mock protocol stats files for the given pids
+// under the following path: /mockProcfsDir/{pid}/net/{snmp,netstat,snmp6}
+func createMockProcfsNetSnmp(pids []int, snmp, netstat, snmp6 []byte) error {
+	for _, pid := range pids {
+		pathToProcessNet := filepath.Join(mockProcfsDir, fmt.Sprintf("%d", pid), "net")
+		if err := os.MkdirAll(pathToProcessNet, os.ModePerm); err != nil {
+			return err
+		}
+
+		if err := createFile(pathToProcessNet, "snmp", snmp); err != nil {
+			return err
+		}
+
+		if err := createFile(pathToProcessNet, "netstat", netstat); err != nil {
+			return err
+		}
+
+		if err := createFile(pathToProcessNet, "snmp6", snmp6); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
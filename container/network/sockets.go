@@ -0,0 +1,289 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// unix socket types as listed in net/unix
+	unixTypeStream    = "0001"
+	unixTypeDgram     = "0002"
+	unixTypeSeqPacket = "0005"
+
+	// unix socket states as listed in net/unix
+	unixStateUnconnected   = "01"
+	unixStateConnecting    = "02"
+	unixStateConnected     = "03"
+	unixStateDisconnecting = "04"
+
+	// unix socket flag __SO_ACCEPTCON which is set for listening sockets
+	unixFlagAcceptCon = 0x10000
+)
+
+// Udp collects statistics about UDP sockets from net/udp or net/udp6
+type Udp struct {
+	StatsFile string
+}
+
+func (udp *Udp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	path, err := statsFilePath(opts, udp.StatsFile)
+	if err != nil {
+		return err
+	}
+
+	switch udp.StatsFile {
+	case "net/udp":
+		stats.Connection.Udp, err = scanUdpStats(path)
+	case "net/udp6":
+		stats.Connection.Udp6, err = scanUdpStats(path)
+	default:
+		return fmt.Errorf("Unknown udp stats file %s", udp.StatsFile)
+	}
+
+	if err != nil {
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get udp stats, stats file %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// Raw collects statistics about RAW sockets from net/raw or net/raw6
+type Raw struct {
+	StatsFile string
+}
+
+func (raw *Raw) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	path, err := statsFilePath(opts, raw.StatsFile)
+	if err != nil {
+		return err
+	}
+
+	switch raw.StatsFile {
+	case "net/raw":
+		stats.Connection.Raw, err = scanUdpStats(path)
+	case "net/raw6":
+		stats.Connection.Raw6, err = scanUdpStats(path)
+	default:
+		return fmt.Errorf("Unknown raw stats file %s", raw.StatsFile)
+	}
+
+	if err != nil {
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get raw stats, stats file %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// Unix collects statistics about UNIX domain sockets from net/unix
+type Unix struct{}
+
+func (unix *Unix) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	path, err := statsFilePath(opts, "net/unix")
+	if err != nil {
+		return err
+	}
+
+	stats.Connection.Unix, err = scanUnixStats(path)
+	if err != nil {
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get unix stats, stats file %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// statsFilePath returns path to the given stats file, for a container it is `<procfs>/<pid>/<statsFile>`
+// and for the host `<procfs>/<statsFile>`
+func statsFilePath(opts container.GetStatOpt, statsFile string) (string, error) {
+	pid, err := opts.GetIntValue("pid")
+	if err != nil {
+		return "", err
+	}
+
+	isHost, err := opts.GetBoolValue("is_host")
+	if err != nil {
+		return "", err
+	}
+
+	procfs, err := opts.GetStringValue("procfs")
+	if err != nil {
+		return "", err
+	}
+
+	if isHost {
+		return filepath.Join(procfs, statsFile), nil
+	}
+
+	return filepath.Join(procfs, strconv.Itoa(pid), statsFile), nil
+}
+
+// scanUdpStats returns statistics of sockets listed in the file in format of net/udp (net/udp6, net/raw and net/raw6 have the same format)
+func scanUdpStats(udpStatsFile string) (container.UdpStat, error) {
+	var stats container.UdpStat
+
+	file, err := os.Open(udpStatsFile)
+	if err != nil {
+		return stats, fmt.Errorf("failure opening %s: %v", udpStatsFile, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	// Discard header line
+	if b := scanner.Scan(); !b {
+		return stats, scanner.Err()
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		// Format: sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ref pointer drops
+		if len(fields) < 13 {
+			return container.UdpStat{}, fmt.Errorf("invalid format of UDP stats file %s: %v", udpStatsFile, line)
+		}
+
+		txQueue, rxQueue, err := parseQueues(fields[4])
+		if err != nil {
+			return container.UdpStat{}, fmt.Errorf("invalid UDP stats line (%v): %v", err, line)
+		}
+
+		drops, err := strconv.ParseUint(fields[12], 10, 64)
+		if err != nil {
+			return container.UdpStat{}, fmt.Errorf("invalid UDP stats line (%v): %v", err, line)
+		}
+
+		stats.Sockets++
+		stats.TxQueue += txQueue
+		stats.RxQueue += rxQueue
+		stats.Drops += drops
+	}
+	if err := scanner.Err(); err != nil {
+		return container.UdpStat{}, err
+	}
+
+	return stats, nil
+}
+
+// scanUnixStats returns statistics of sockets listed in the file in format of net/unix
+func scanUnixStats(unixStatsFile string) (container.UnixStat, error) {
+	var stats container.UnixStat
+
+	file, err := os.Open(unixStatsFile)
+	if err != nil {
+		return stats, fmt.Errorf("failure opening %s: %v", unixStatsFile, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	// Discard header line
+	if b := scanner.Scan(); !b {
+		return stats, scanner.Err()
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		// Format: Num RefCount Protocol Flags Type St Inode Path (path is optional)
+		if len(fields) < 7 {
+			return container.UnixStat{}, fmt.Errorf("invalid format of UNIX stats file %s: %v", unixStatsFile, line)
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 64)
+		if err != nil {
+			return container.UnixStat{}, fmt.Errorf("invalid UNIX stats line (%v): %v", err, line)
+		}
+
+		switch fields[4] {
+		case unixTypeStream:
+			stats.Stream++
+		case unixTypeDgram:
+			stats.Dgram++
+		case unixTypeSeqPacket:
+			stats.SeqPacket++
+		}
+
+		switch fields[5] {
+		case unixStateUnconnected:
+			stats.Unconnected++
+		case unixStateConnecting:
+			stats.Connecting++
+		case unixStateConnected:
+			stats.Connected++
+		case unixStateDisconnecting:
+			stats.Disconnecting++
+		}
+
+		if flags&unixFlagAcceptCon != 0 {
+			stats.Listening++
+		}
+
+		stats.Sockets++
+	}
+	if err := scanner.Err(); err != nil {
+		return container.UnixStat{}, err
+	}
+
+	return stats, nil
+}
+
+// parseQueues returns sizes of transmit and receive queue from field in format `tx_queue:rx_queue` (hexadecimal values)
+func parseQueues(field string) (txQueue uint64, rxQueue uint64, _ error) {
+	queues := strings.Split(field, ":")
+	if len(queues) != 2 {
+		return 0, 0, fmt.Errorf("invalid format of queues %s", field)
+	}
+
+	txQueue, err := strconv.ParseUint(queues[0], 16, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rxQueue, err = strconv.ParseUint(queues[1], 16, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return txQueue, rxQueue, nil
+}
@@ -0,0 +1,183 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+var mockUdpContent = []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  123: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 17540 2 ffff8880b6a1a000 0
+  456: 00000000:0044 00000000:0000 07 00000010:00000200 00:00000000 00000000     0        0 21384 2 ffff8880b6a1b000 5
+  789: 0100007F:1F90 0100007F:C350 01 00000001:00000000 00:00000000 00000000     0        0 30215 2 ffff8880b6a1c000 2`)
+
+var mockRawContent = []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+    1: 00000000:0001 00000000:0000 07 00000000:00000100 00:00000000 00000000     0        0 41220 2 ffff8880b6a1d000 3`)
+
+var mockUnixContent = []byte(`Num       RefCount Protocol Flags    Type St Inode Path
+ffff8880b6a1e000: 00000002 00000000 00010000 0001 01 17551 /run/systemd/private
+ffff8880b6a1e400: 00000003 00000000 00000000 0001 03 20843
+ffff8880b6a1e800: 00000003 00000000 00000000 0001 03 20844 /run/dbus/system_bus_socket
+ffff8880b6a1ec00: 00000002 00000000 00000000 0002 01 12345 /run/systemd/notify
+ffff8880b6a1f000: 00000002 00000000 00000000 0005 02 12346`)
+
+func TestSocketsStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get UDP, RAW and UNIX socket stats from procfs", t, func() {
+
+		Convey("create socket stats files for mock pids", func() {
+			err := createMockProcfsNetSockets(mockPids, mockUdpContent, mockRawContent, mockUnixContent)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("successful retrieving UDP statistics", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/udp")
+				stats, err := scanUdpStats(path)
+				So(err, ShouldBeNil)
+				So(stats.Sockets, ShouldEqual, 3)
+				So(stats.TxQueue, ShouldEqual, 17)
+				So(stats.RxQueue, ShouldEqual, 512)
+				So(stats.Drops, ShouldEqual, 7)
+			}
+		})
+
+		Convey("successful retrieving RAW statistics", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/raw")
+				stats, err := scanUdpStats(path)
+				So(err, ShouldBeNil)
+				So(stats.Sockets, ShouldEqual, 1)
+				So(stats.TxQueue, ShouldEqual, 0)
+				So(stats.RxQueue, ShouldEqual, 256)
+				So(stats.Drops, ShouldEqual, 3)
+			}
+		})
+
+		Convey("successful retrieving UNIX statistics", func() {
+			for _, pid := range mockPids {
+				path := filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net/unix")
+				stats, err := scanUnixStats(path)
+				So(err, ShouldBeNil)
+				So(stats.Sockets, ShouldEqual, 5)
+				So(stats.Stream, ShouldEqual, 3)
+				So(stats.Dgram, ShouldEqual, 1)
+				So(stats.SeqPacket, ShouldEqual, 1)
+				So(stats.Unconnected, ShouldEqual, 2)
+				So(stats.Connecting, ShouldEqual, 1)
+				So(stats.Connected, ShouldEqual, 2)
+				So(stats.Disconnecting, ShouldEqual, 0)
+				So(stats.Listening, ShouldEqual, 1)
+			}
+		})
+
+		Convey("successful setting socket statistics for a container", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir}
+
+			So((&Udp{StatsFile: "net/udp6"}).GetStats(stats, opts), ShouldBeNil)
+			So(stats.Connection.Udp6.Sockets, ShouldEqual, 3)
+
+			So((&Raw{StatsFile: "net/raw"}).GetStats(stats, opts), ShouldBeNil)
+			So(stats.Connection.Raw.Drops, ShouldEqual, 3)
+
+			So((&Unix{}).GetStats(stats, opts), ShouldBeNil)
+			So(stats.Connection.Unix.Listening, ShouldEqual, 1)
+		})
+
+		Convey("successful setting socket statistics for the host", func() {
+			err := createMockProcfsNetSockets(nil, mockUdpContent, mockRawContent, mockUnixContent)
+			So(err, ShouldBeNil)
+
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": 1, "is_host": true, "procfs": mockProcfsDir}
+
+			So((&Udp{StatsFile: "net/udp"}).GetStats(stats, opts), ShouldBeNil)
+			So(stats.Connection.Udp.Sockets, ShouldEqual, 3)
+		})
+
+		Convey("return an error when the stats file is unknown", func() {
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir}
+
+			So((&Udp{StatsFile: "net/tcp"}).GetStats(stats, opts), ShouldNotBeNil)
+			So((&Raw{StatsFile: "net/udp"}).GetStats(stats, opts), ShouldNotBeNil)
+		})
+
+		Convey("return an error when the given PID does not exist", func() {
+			_, err := scanUdpStats(filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/udp"))
+			So(err, ShouldNotBeNil)
+
+			_, err = scanUnixStats(filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/unix"))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when content is invalid", func() {
+			mockPid := 1
+			invalidContent := []byte("header\n 0: invalid")
+			err := createMockProcfsNetSockets([]int{mockPid}, invalidContent, invalidContent, invalidContent)
+			So(err, ShouldBeNil)
+
+			_, err = scanUdpStats(filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "net/udp"))
+			So(err, ShouldNotBeNil)
+
+			_, err = scanUnixStats(filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "net/unix"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// createMockProcfsNetSockets creates mock socket stats files for the given pids
+// under the following path: /mockProcfsDir/{pid}/net/{udp,udp6,raw,raw6,unix},
+// for empty list of pids files are created for the host under /mockProcfsDir/net
+func createMockProcfsNetSockets(pids []int, udp, raw, unix []byte) error {
+	dirs := []string{filepath.Join(mockProcfsDir, "net")}
+	if len(pids) > 0 {
+		dirs = []string{}
+		for _, pid := range pids {
+			dirs = append(dirs, filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net"))
+		}
+	}
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+
+		files := map[string][]byte{"udp": udp, "udp6": udp, "raw": raw, "raw6": raw, "unix": unix}
+		for name, content := range files {
+			if err := createFile(dir, name, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
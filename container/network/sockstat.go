@@ -0,0 +1,120 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sockstatProtocols maps protocol names used in net/sockstat and net/sockstat6 to the names exposed in metrics namespace
+var sockstatProtocols = map[string]string{
+	"sockets":  "sockets",
+	"TCP":      "tcp",
+	"UDP":      "udp",
+	"UDPLITE":  "udp_lite",
+	"RAW":      "raw",
+	"FRAG":     "frag",
+	"TCP6":     "tcp6",
+	"UDP6":     "udp6",
+	"UDPLITE6": "udp_lite6",
+	"RAW6":     "raw6",
+	"FRAG6":    "frag6",
+}
+
+// Sockstat collects summary of sockets usage (sockets in use, orphaned sockets, memory, etc.)
+// from net/sockstat and net/sockstat6
+type Sockstat struct{}
+
+func (s *Sockstat) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	for _, statsFile := range []string{"net/sockstat", "net/sockstat6"} {
+		path, err := statsFilePath(opts, statsFile)
+		if err != nil {
+			return err
+		}
+
+		sockstat, err := scanSockstatStats(path)
+		if err != nil {
+			// only log error message
+			log.WithFields(log.Fields{
+				"module": "network",
+				"block":  "GetStats",
+			}).Errorf("Unable to get sockets summary, stats file %s: %s", path, err)
+			continue
+		}
+
+		for proto, values := range sockstat {
+			stats.Connection.Sockstat[proto] = values
+		}
+	}
+
+	return nil
+}
+
+// scanSockstatStats parses file in format of net/sockstat and net/sockstat6 where each line describes
+// a protocol by pairs of counter name and its value (e.g. `TCP: inuse 5 orphan 0 tw 2 alloc 7 mem 1`)
+func scanSockstatStats(sockstatFile string) (map[string]map[string]uint64, error) {
+	file, err := os.Open(sockstatFile)
+	if err != nil {
+		return nil, fmt.Errorf("failure opening %s: %v", sockstatFile, err)
+	}
+	defer file.Close()
+
+	stats := map[string]map[string]uint64{}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields)%2 != 1 {
+			return nil, fmt.Errorf("invalid format of sockets summary file %s: %v", sockstatFile, fields)
+		}
+
+		proto, ok := sockstatProtocols[strings.TrimSuffix(fields[0], ":")]
+		if !ok {
+			// skip protocols which are not supported
+			continue
+		}
+
+		counters := map[string]uint64{}
+		for i := 1; i < len(fields); i += 2 {
+			val, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse sockets summary (%v): %v", err, fields)
+			}
+			counters[fields[i]] = val
+		}
+		stats[proto] = counters
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
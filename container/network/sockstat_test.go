@@ -0,0 +1,119 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
+)
+
+var mockSockstatContent = []byte(`sockets: used 290
+TCP: inuse 5 orphan 1 tw 2 alloc 7 mem 3
+UDP: inuse 3 mem 2
+UDPLITE: inuse 0
+RAW: inuse 1
+FRAG: inuse 0 memory 0`)
+
+var mockSockstat6Content = []byte(`TCP6: inuse 4
+UDP6: inuse 2
+UDPLITE6: inuse 0
+RAW6: inuse 0
+FRAG6: inuse 0 memory 0`)
+
+func TestSockstatStatsFromProc(t *testing.T) {
+	defer deleteMockFiles()
+
+	Convey("Get sockets summary from procfs", t, func() {
+
+		Convey("create sockets summary files for mock pids and the host", func() {
+			dirs := []string{filepath.Join(mockProcfsDir, "net")}
+			for _, pid := range mockPids {
+				dirs = append(dirs, filepath.Join(mockProcfsDir, strconv.Itoa(pid), "net"))
+			}
+			for _, dir := range dirs {
+				So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+				So(createFile(dir, "sockstat", mockSockstatContent), ShouldBeNil)
+				So(createFile(dir, "sockstat6", mockSockstat6Content), ShouldBeNil)
+			}
+		})
+
+		Convey("successful retrieving counters from net/sockstat", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPids[0]), "net/sockstat")
+			stats, err := scanSockstatStats(path)
+			So(err, ShouldBeNil)
+			So(stats["sockets"]["used"], ShouldEqual, 290)
+			So(stats["tcp"], ShouldResemble, map[string]uint64{"inuse": 5, "orphan": 1, "tw": 2, "alloc": 7, "mem": 3})
+			So(stats["udp"]["mem"], ShouldEqual, 2)
+			So(stats["frag"], ShouldContainKey, "memory")
+			So(stats, ShouldContainKey, "udp_lite")
+		})
+
+		Convey("successful retrieving counters from net/sockstat6", func() {
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPids[0]), "net/sockstat6")
+			stats, err := scanSockstatStats(path)
+			So(err, ShouldBeNil)
+			So(stats["tcp6"]["inuse"], ShouldEqual, 4)
+			So(stats["udp6"]["inuse"], ShouldEqual, 2)
+			So(stats, ShouldContainKey, "udp_lite6")
+		})
+
+		Convey("successful setting sockets summary for a container and the host", func() {
+			for _, opts := range []container.GetStatOpt{
+				{"pid": mockPids[0], "is_host": false, "procfs": mockProcfsDir},
+				{"pid": -1, "is_host": true, "procfs": mockProcfsDir},
+			} {
+				stats := container.NewStatistics()
+				err := (&Sockstat{}).GetStats(stats, opts)
+				So(err, ShouldBeNil)
+				So(stats.Connection.Sockstat["tcp"]["orphan"], ShouldEqual, 1)
+				So(stats.Connection.Sockstat["tcp6"]["inuse"], ShouldEqual, 4)
+			}
+		})
+
+		Convey("return an error when the given PID does not exist", func() {
+			stats, err := scanSockstatStats(filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/sockstat"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+		})
+
+		Convey("return an error when content is invalid", func() {
+			dir := filepath.Join(mockProcfsDir, strconv.Itoa(1), "net")
+			So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+
+			So(createFile(dir, "sockstat", []byte(`TCP: inuse`)), ShouldBeNil)
+			stats, err := scanSockstatStats(filepath.Join(dir, "sockstat"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+
+			So(createFile(dir, "sockstat", []byte(`TCP: inuse -1`)), ShouldBeNil)
+			stats, err = scanSockstatStats(filepath.Join(dir, "sockstat"))
+			So(err, ShouldNotBeNil)
+			So(stats, ShouldBeNil)
+		})
+	})
+}
@@ -40,7 +40,6 @@ import (
 	"bufio"
 	"fmt"
 	"io/ioutil"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -54,45 +53,30 @@ type Tcp struct {
 }
 
 func (tcp *Tcp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
-	pid, err := opts.GetIntValue("pid")
+	path, err := statsFilePath(opts, tcp.StatsFile)
 	if err != nil {
 		return err
 	}
 
-	isHost, err := opts.GetBoolValue("is_host")
-	if err != nil {
-		return err
+	switch tcp.StatsFile {
+	case "net/tcp":
+		stats.Connection.Tcp, err = tcpStatsFromProc(path)
+	case "net/tcp6":
+		stats.Connection.Tcp6, err = tcpStatsFromProc(path)
+	default:
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unknown tcp stats file %s", tcp.StatsFile)
+		return fmt.Errorf("Unknown tcp stats file %s", tcp.StatsFile)
 	}
 
-	procfs, err := opts.GetStringValue("procfs")
 	if err != nil {
-		return err
-	}
-
-	if !isHost {
-		path := filepath.Join(procfs, strconv.Itoa(pid), tcp.StatsFile)
-
-		switch tcp.StatsFile {
-		case "net/tcp":
-			stats.Connection.Tcp, err = tcpStatsFromProc(path)
-		case "net/tcp6":
-			stats.Connection.Tcp6, err = tcpStatsFromProc(path)
-		default:
-			log.WithFields(log.Fields{
-				"module": "network",
-				"block":  "GetStats",
-			}).Errorf("Unknown tcp stats file %s", tcp.StatsFile)
-			return fmt.Errorf("Unknown tcp stats file %s", tcp.StatsFile)
-		}
-
-		if err != nil {
-			// only log error message
-			log.WithFields(log.Fields{
-				"module": "network",
-				"block":  "GetStats",
-			}).Errorf("Unable to get network stats, pid %d, stats file %s: %s", pid, tcp.StatsFile, err)
-		}
-
+		// only log error message
+		log.WithFields(log.Fields{
+			"module": "network",
+			"block":  "GetStats",
+		}).Errorf("Unable to get network stats, stats file %s: %s", path, err)
 	}
 
 	return nil
@@ -110,11 +94,6 @@ func tcpStatsFromProc(tcpStatsFile string) (container.TcpStat, error) {
 func scanTcpStats(tcpStatsFile string) (container.TcpStat, error) {
 	var stats container.TcpStat
 
-	data, err := ioutil.ReadFile(tcpStatsFile)
-	if err != nil {
-		return stats, fmt.Errorf("Cannot open %s: %v", tcpStatsFile, err)
-	}
-
 	tcpStateMap := map[string]uint64{
 		"01": 0, //ESTABLISHED
 		"02": 0, //SYN_SENT
@@ -129,6 +108,66 @@ func scanTcpStats(tcpStatsFile string) (container.TcpStat, error) {
 		"0B": 0, //CLOSING
 	}
 
+	err := scanTcpSockets(tcpStatsFile, func(line string, state []string) error {
+		tcpState := state[3]
+		_, ok := tcpStateMap[tcpState]
+		if !ok {
+			return fmt.Errorf("invalid TCP stats line: %v", line)
+		}
+		tcpStateMap[tcpState]++
+
+		txQueue, rxQueue, err := parseQueues(state[4])
+		if err != nil {
+			return fmt.Errorf("invalid TCP stats line (%v): %v", err, line)
+		}
+
+		if tcpState == tcpStateListen {
+			// for listening socket rx_queue holds the number of connections waiting in accept queue
+			port, err := parsePort(state[1])
+			if err != nil {
+				return fmt.Errorf("invalid TCP stats line (%v): %v", err, line)
+			}
+			if stats.ListenBacklog == nil {
+				stats.ListenBacklog = map[string]uint64{}
+			}
+			stats.ListenBacklog[port] += rxQueue
+			return nil
+		}
+
+		stats.TxQueue += txQueue
+		stats.RxQueue += rxQueue
+		if txQueue > 0 || rxQueue > 0 {
+			stats.NonEmptyQueues++
+		}
+		return nil
+	})
+	if err != nil {
+		return container.TcpStat{}, err
+	}
+
+	stats.Established = tcpStateMap["01"]
+	stats.SynSent = tcpStateMap["02"]
+	stats.SynRecv = tcpStateMap["03"]
+	stats.FinWait1 = tcpStateMap["04"]
+	stats.FinWait2 = tcpStateMap["05"]
+	stats.TimeWait = tcpStateMap["06"]
+	stats.Close = tcpStateMap["07"]
+	stats.CloseWait = tcpStateMap["08"]
+	stats.LastAck = tcpStateMap["09"]
+	stats.Listen = tcpStateMap["0A"]
+	stats.Closing = tcpStateMap["0B"]
+
+	return stats, nil
+}
+
+// scanTcpSockets reads sockets listed in net/tcp or net/tcp6 file and passes each of them to the handler,
+// fields of socket line are guaranteed to contain at least local_address, rem_address, st and tx_queue:rx_queue
+func scanTcpSockets(tcpStatsFile string, handle func(line string, fields []string) error) error {
+	data, err := ioutil.ReadFile(tcpStatsFile)
+	if err != nil {
+		return fmt.Errorf("Cannot open %s: %v", tcpStatsFile, err)
+	}
+
 	reader := strings.NewReader(string(data))
 	scanner := bufio.NewScanner(reader)
 
@@ -136,40 +175,38 @@ func scanTcpStats(tcpStatsFile string) (container.TcpStat, error) {
 
 	// Discard header line
 	if b := scanner.Scan(); !b {
-		return stats, scanner.Err()
+		return scanner.Err()
 	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		state := strings.Fields(line)
-
-		if len(state) < 4 {
-			return stats, fmt.Errorf("invalid format of TCP stats file %s: %v", tcpStatsFile, line)
-		}
+		fields := strings.Fields(line)
 
 		// TCP state is the 4th field.
 		// Format: sl local_address rem_address st tx_queue rx_queue tr tm->when retrnsmt  uid timeout inode
-		tcpState := state[3]
-		_, ok := tcpStateMap[tcpState]
-		if !ok {
-			return stats, fmt.Errorf("invalid TCP stats line: %v", line)
+		if len(fields) < 5 {
+			return fmt.Errorf("invalid format of TCP stats file %s: %v", tcpStatsFile, line)
+		}
+
+		if err := handle(line, fields); err != nil {
+			return err
 		}
-		tcpStateMap[tcpState]++
 	}
 
-	stats = container.TcpStat{
-		Established: tcpStateMap["01"],
-		SynSent:     tcpStateMap["02"],
-		SynRecv:     tcpStateMap["03"],
-		FinWait1:    tcpStateMap["04"],
-		FinWait2:    tcpStateMap["05"],
-		TimeWait:    tcpStateMap["06"],
-		Close:       tcpStateMap["07"],
-		CloseWait:   tcpStateMap["08"],
-		LastAck:     tcpStateMap["09"],
-		Listen:      tcpStateMap["0A"],
-		Closing:     tcpStateMap["0B"],
+	return scanner.Err()
+}
+
+// parsePort returns decimal port number from address in format `address:port` (hexadecimal values)
+func parsePort(address string) (string, error) {
+	i := strings.LastIndex(address, ":")
+	if i < 0 {
+		return "", fmt.Errorf("invalid format of address %s", address)
 	}
 
-	return stats, nil
+	port, err := strconv.ParseUint(address[i+1:], 16, 16)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(port, 10), nil
 }
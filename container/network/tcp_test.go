@@ -22,11 +22,14 @@ limitations under the License.
 package network
 
 import (
+	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap-plugin-collector-docker/container"
 )
 
 // docker container's process ID points to its tcp stats in /proc/{pid}/net/tcp
@@ -44,6 +47,14 @@ var mockTcpContent = []byte(`sl  local_address rem_address   st tx_queue rx_queu
 				   6: 0100007F:B1FE 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 19907 1 ffff8807e0fc0000 100 0 0 10 0
 				   7: 1E7E5B0A:8CE4 315D1332:01BB 01 00000000:00000000 00:00000000 00000000     0        0 5118203 1 ffff8806a1817800 20 0 0 10 -1`)
 
+var mockTcpQueuesContent = []byte(`sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+				   0: 00000000:1F90 00000000:0000 0A 00000000:00000003 00:00000000 00000000     0        0 2638360 1 ffff8805af412800 100 0 0 10 0
+				   1: 0100007F:1F90 00000000:0000 0A 00000000:00000002 00:00000000 00000000     0        0 2638361 1 ffff8805af412c00 100 0 0 10 0
+				   2: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 9574802 1 ffff8807df908800 100 0 0 10 0
+				   3: 1E7E5B0A:1F90 315D1332:C350 01 00000010:00000020 01:00000014 00000000     0        0 5118203 1 ffff8806a1817800 20 0 0 10 -1
+				   4: 1E7E5B0A:1F90 315D1332:C351 01 00000000:00000001 00:00000000 00000000     0        0 5118204 1 ffff8806a1817c00 20 0 0 10 -1
+				   5: 1E7E5B0A:1F90 315D1332:C352 01 00000000:00000000 00:00000000 00000000     0        0 5118205 1 ffff8806a1818000 20 0 0 10 -1`)
+
 func TestTcpStatsFromProc(t *testing.T) {
 	defer deleteMockFiles()
 
@@ -78,6 +89,37 @@ func TestTcpStatsFromProc(t *testing.T) {
 
 		})
 
+		Convey("successful retrieving TCP queues and listen backlog", func() {
+			mockPid := 3
+			err := createMockProcfsNetTCP([]int{mockPid}, mockTcpQueuesContent)
+			So(err, ShouldBeNil)
+			path := filepath.Join(mockProcfsDir, strconv.Itoa(mockPid), "net/tcp")
+			tcpStats, err := tcpStatsFromProc(path)
+			So(err, ShouldBeNil)
+			So(tcpStats.Listen, ShouldEqual, 3)
+			So(tcpStats.Established, ShouldEqual, 3)
+			// listening sockets are excluded from sums of queues
+			So(tcpStats.TxQueue, ShouldEqual, 16)
+			So(tcpStats.RxQueue, ShouldEqual, 33)
+			So(tcpStats.NonEmptyQueues, ShouldEqual, 2)
+			// backlog of sockets listening on the same port is summed up
+			So(tcpStats.ListenBacklog, ShouldResemble, map[string]uint64{"8080": 5, "22": 0})
+		})
+
+		Convey("successful retrieving TCP/TCP6 statistics for the host", func() {
+			pathToHostNet := filepath.Join(mockProcfsDir, "net")
+			So(os.MkdirAll(pathToHostNet, os.ModePerm), ShouldBeNil)
+			So(createFile(pathToHostNet, "tcp", mockTcpContent), ShouldBeNil)
+			So(createFile(pathToHostNet, "tcp6", mockTcpQueuesContent), ShouldBeNil)
+
+			stats := container.NewStatistics()
+			opts := container.GetStatOpt{"pid": -1, "is_host": true, "procfs": mockProcfsDir}
+			So((&Tcp{StatsFile: "net/tcp"}).GetStats(stats, opts), ShouldBeNil)
+			So((&Tcp{StatsFile: "net/tcp6"}).GetStats(stats, opts), ShouldBeNil)
+			So(stats.Connection.Tcp.Listen, ShouldEqual, 7)
+			So(stats.Connection.Tcp6.Listen, ShouldEqual, 3)
+		})
+
 		Convey("return an error when the given PID does not exist", func() {
 			path := filepath.Join(mockProcfsDir, strconv.Itoa(0), "net/tcp")
 			tcpStats, err := tcpStatsFromProc(path)
@@ -86,6 +86,111 @@ type ContainerData struct {
 
 	// Container's statistics (cpu usage, memory usage, network stats, etc.)
 	Stats *Statistics `json:"stats,omitempty"`
+
+	// Stopped is set for containers which are not running, only their specification is available
+	Stopped bool `json:"-"`
+
+	// Exited is set for final snapshot of container which has exited since the last collection
+	Exited bool `json:"-"`
+
+	// Inventory of docker networks keyed by network name (available only for host)
+	Networks map[string]NetworkInventory `json:"networks,omitempty"`
+
+	// Numbers of docker events of the container (totals of all containers for host)
+	Events EventsStats `json:"events"`
+
+	// Disk usage of docker objects (available only for host)
+	DiskUsage DiskUsage `json:"disk_usage"`
+}
+
+// DiskUsage holds disk usage of docker objects as reported by `docker system df`, sizes are in bytes
+type DiskUsage struct {
+	Images     DiskUsageSummary `json:"images"`
+	Containers DiskUsageSummary `json:"containers"`
+	Volumes    DiskUsageSummary `json:"volumes"`
+	BuildCache DiskUsageSummary `json:"build_cache"`
+	// Disk usage of local volumes keyed by volume name
+	Volume map[string]VolumeUsage `json:"volume,omitempty"`
+}
+
+// DiskUsageSummary holds number of docker objects of one type and space used by them
+type DiskUsageSummary struct {
+	Count       uint64 `json:"count"`
+	Active      uint64 `json:"active"`
+	Total       uint64 `json:"total"`
+	Reclaimable uint64 `json:"reclaimable"`
+}
+
+// VolumeUsage holds size of docker volume and number of containers referencing it
+type VolumeUsage struct {
+	Size     uint64 `json:"size"`
+	RefCount uint64 `json:"ref_count"`
+}
+
+// DaemonInfo holds information about docker engine and objects managed by it, as reported by `docker info` and `docker version`
+type DaemonInfo struct {
+	ContainersRunning uint64 `json:"containers_running"`
+	ContainersPaused  uint64 `json:"containers_paused"`
+	ContainersStopped uint64 `json:"containers_stopped"`
+	Images            uint64 `json:"images"`
+	Goroutines        uint64 `json:"goroutines"`
+	FileDescriptors   uint64 `json:"file_descriptors"`
+	EventsListeners   uint64 `json:"events_listeners"`
+	MemTotal          uint64 `json:"mem_total"`
+	NCPU              uint64 `json:"ncpu"`
+	Warnings          uint64 `json:"warnings"`
+	Version           string `json:"version"`
+	APIVersion        string `json:"api_version"`
+}
+
+// DaemonConnection holds state of connection of the plugin to docker daemon
+type DaemonConnection struct {
+	//Set to 1 when docker daemon responds, 0 when it is down and metrics of containers are not available
+	Up uint64 `json:"up"`
+	//Count of successful and failed attempts to reconnect docker daemon which has been down
+	Reconnects        uint64 `json:"reconnects"`
+	ReconnectFailures uint64 `json:"reconnect_failures"`
+}
+
+// ImageInventory holds information about docker image and its usage by containers
+type ImageInventory struct {
+	//Full id of image, e.g. sha256:<hash>
+	ID string `json:"id"`
+	//Size of image and its virtual size (including shared layers) in bytes
+	Size        uint64 `json:"size"`
+	VirtualSize uint64 `json:"virtual_size"`
+	//Seconds since the image was created
+	Age uint64 `json:"age"`
+	//Count of containers (running or not) created from image
+	Containers uint64 `json:"containers"`
+	//Flags set to 1 when image has neither tag nor digest, and when it is not used by any container
+	Dangling uint64 `json:"dangling"`
+	Unused   uint64 `json:"unused"`
+}
+
+// EventsStats holds numbers of docker events and durations of the latest lifecycle transitions observed since monitoring of events started
+type EventsStats struct {
+	Count    EventCounters  `json:"count"`
+	Duration EventDurations `json:"duration"`
+	// Numbers of docker events per image (available only for host)
+	Image map[string]EventCounters `json:"image,omitempty"`
+}
+
+// EventCounters holds numbers of docker events by their action
+type EventCounters struct {
+	Start        uint64 `json:"start"`
+	Die          uint64 `json:"die"`
+	Oom          uint64 `json:"oom"`
+	Kill         uint64 `json:"kill"`
+	Restart      uint64 `json:"restart"`
+	HealthStatus uint64 `json:"health_status"`
+	ExecCreate   uint64 `json:"exec_create"`
+}
+
+// EventDurations holds durations (in seconds) of the latest lifecycle transitions
+type EventDurations struct {
+	CreateToStart float64 `json:"create_to_start"`
+	KillToDie     float64 `json:"kill_to_die"`
 }
 
 type Statistics struct {
@@ -93,16 +198,108 @@ type Statistics struct {
 	Network    []NetworkInterface             `json:"network,omitempty"`
 	Connection TcpInterface                   `json:"connection,omitempty"`
 	Filesystem map[string]FilesystemInterface `json:"filesystem,omitempty"`
+	// Statistics of qdiscs and classes per network interface and handle, exposed under network interface
+	Qdisc map[string]map[string]QdiscStat `json:"-"`
 }
 
 // Specification holds docker container specification
 type Specification struct {
-	Status     string            `json:"status,omitempty"`
-	Created    string            `json:"creation_time,omitempty"`
-	Image      string            `json:"image_name,omitempty"`
-	SizeRw     int64             `json:"size_rw,omitempty"`
-	SizeRootFs int64             `json:"size_root_fs,omitempty"`
-	Labels     map[string]string `json:"labels,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Created string `json:"creation_time,omitempty"`
+	Image   string `json:"image_name,omitempty"`
+	// Id and digest of the image, image name is the one used to create the container which can refer to other image later
+	ImageID     string            `json:"image_id,omitempty"`
+	ImageDigest string            `json:"image_digest,omitempty"`
+	SizeRw      int64             `json:"size_rw,omitempty"`
+	SizeRootFs  int64             `json:"size_root_fs,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// Docker networks to which the container is attached, keyed by network name
+	Networks map[string]NetworkSpec `json:"networks,omitempty"`
+	// Lifecycle state of the container in numeric form
+	State StateSpec `json:"state"`
+	// Status of the container's healthcheck and results of its recent probes
+	Health HealthSpec `json:"health"`
+	// Configuration of the container
+	Name              string `json:"name,omitempty"`
+	Command           string `json:"command,omitempty"`
+	Entrypoint        string `json:"entrypoint,omitempty"`
+	RestartPolicy     string `json:"restart_policy,omitempty"`
+	RestartMaxRetries uint64 `json:"restart_max_retries"`
+	LogDriver         string `json:"log_driver,omitempty"`
+	Runtime           string `json:"runtime,omitempty"`
+	Mounts            uint64 `json:"mounts"`
+	PublishedPorts    uint64 `json:"published_ports"`
+	// Resource limits configured for the container
+	Limits LimitsSpec `json:"limits"`
+}
+
+// LimitsSpec holds resource limits configured for container (0 means no limit)
+type LimitsSpec struct {
+	//Memory limit in bytes
+	Memory int64 `json:"memory"`
+	//Limit of memory and swap together in bytes, -1 means unlimited swap
+	MemorySwap int64 `json:"memory_swap"`
+	//CPU limit in units of 10^-9 CPUs (docker run --cpus)
+	NanoCpus int64 `json:"nano_cpus"`
+	//CPU time in microseconds available for the container per CFS period
+	CpuQuota int64 `json:"cpu_quota"`
+	//CPUs on which the container is allowed to run
+	CpusetCpus string `json:"cpuset_cpus,omitempty"`
+	//Limit of the number of processes in the container
+	PidsLimit int64 `json:"pids_limit"`
+	//Relative weight of block IO
+	BlkioWeight int64 `json:"blkio_weight"`
+	//Ulimits keyed by their name
+	Ulimits map[string]UlimitSpec `json:"ulimits,omitempty"`
+}
+
+// UlimitSpec holds soft and hard value of ulimit
+type UlimitSpec struct {
+	Soft int64 `json:"soft"`
+	Hard int64 `json:"hard"`
+}
+
+// HealthSpec holds status of container healthcheck and summary of probes retained in its log
+type HealthSpec struct {
+	//Code of health status: 0 - no healthcheck, 1 - starting, 2 - healthy, 3 - unhealthy
+	Status uint64 `json:"status"`
+	//Count of consecutive failed probes
+	FailingStreak uint64 `json:"failing_streak"`
+	//Exit code of the last probe
+	LastExitCode int64 `json:"last_exit_code"`
+	//Duration of the last probe in seconds
+	LastDuration float64 `json:"last_duration"`
+	//Count of probes retained in health log
+	Probes uint64 `json:"probes"`
+	//Count of failed probes retained in health log
+	FailedProbes uint64 `json:"failed_probes"`
+}
+
+// StateSpec holds lifecycle state of container as reported by docker inspect
+type StateSpec struct {
+	//Code of container state: 0 - created, 1 - running, 2 - paused, 3 - restarting, 4 - removing, 5 - exited, 6 - dead
+	Code uint64 `json:"code"`
+	//Flags set to 1 when the container is in the given state, otherwise 0
+	Running    uint64 `json:"running"`
+	Paused     uint64 `json:"paused"`
+	Restarting uint64 `json:"restarting"`
+	OOMKilled  uint64 `json:"oom_killed"`
+	Dead       uint64 `json:"dead"`
+	//Seconds since the container was started, 0 when it is not running
+	Uptime uint64 `json:"uptime"`
+	//Exit code of the last run of the container
+	ExitCode int64 `json:"exit_code"`
+	//Count of restarts of the container
+	RestartCount uint64 `json:"restart_count"`
+	//Unix timestamp when the container finished its last run, 0 when it has never finished
+	FinishedAt int64 `json:"finished_at"`
+}
+
+// NetworkSpec holds identity of container in a docker network
+type NetworkSpec struct {
+	IPAddress  string `json:"ip_address,omitempty"`
+	MacAddress string `json:"mac_address,omitempty"`
+	Gateway    string `json:"gateway,omitempty"`
 }
 
 type Cgroups struct {
@@ -204,6 +401,38 @@ type NetworkInterface struct {
 	TxDropped uint64 `json:"tx_dropped,omitempty"`
 }
 
+// NetworkInventory holds information about docker network and utilization of its IPv4 address pools
+type NetworkInventory struct {
+	//Network driver, e.g. bridge or overlay
+	Driver string `json:"driver"`
+	//Scope of network, e.g. local or swarm
+	Scope string `json:"scope"`
+	//Count of containers attached to network
+	Containers uint64 `json:"containers"`
+	//Count of IPv4 addresses in address pools of network (IP ranges if they are configured, otherwise subnets)
+	SubnetSize uint64 `json:"subnet_size"`
+	//Count of IPv4 addresses allocated in address pools (addresses of containers, gateways and auxiliary addresses)
+	Allocated uint64 `json:"allocated"`
+	//Ratio of allocated addresses to addresses available for allocation
+	Utilization float64 `json:"utilization"`
+}
+
+// QdiscStat holds statistics of traffic control queueing discipline (or its class) attached to network interface
+type QdiscStat struct {
+	//Count of bytes sent through qdisc
+	Bytes uint64 `json:"bytes"`
+	//Count of packets sent through qdisc
+	Packets uint64 `json:"packets"`
+	//Count of packets dropped by qdisc
+	Drops uint64 `json:"drops"`
+	//Count of times qdisc was over its limit (e.g. packets were delayed by rate limiting)
+	Overlimits uint64 `json:"overlimits"`
+	//Bytes waiting in queue of qdisc
+	Backlog uint64 `json:"backlog"`
+	//Count of packets requeued by qdisc
+	Requeues uint64 `json:"requeues"`
+}
+
 // FilesystemInterface holds statistics about filesystem device, capacity, usage, etc.
 type FilesystemInterface struct {
 	// The block device name associated with the filesystem
@@ -266,10 +495,20 @@ type FilesystemInterface struct {
 	WeightedIoTime uint64 `json:"weighted_io_time,omitempty"`
 }
 
-// TcpInterface holds tcp and tcp6 statistics
+// TcpInterface holds statistics about sockets (tcp, tcp6, udp, udp6, unix, raw, raw6) and protocol counters
 type TcpInterface struct {
-	Tcp  TcpStat `json:"tcp,omitempty"`  // TCP connection stats (Established, Listen, etc.)
-	Tcp6 TcpStat `json:"tcp6,omitempty"` // TCP6 connection stats (Established, Listen, etc.)
+	Tcp       TcpStat                      `json:"tcp,omitempty"`       // TCP connection stats (Established, Listen, etc.)
+	Tcp6      TcpStat                      `json:"tcp6,omitempty"`      // TCP6 connection stats (Established, Listen, etc.)
+	Udp       UdpStat                      `json:"udp,omitempty"`       // UDP socket stats (Sockets, Drops, etc.)
+	Udp6      UdpStat                      `json:"udp6,omitempty"`      // UDP6 socket stats (Sockets, Drops, etc.)
+	Unix      UnixStat                     `json:"unix,omitempty"`      // UNIX socket stats (Stream, Connected, etc.)
+	Raw       UdpStat                      `json:"raw,omitempty"`       // RAW socket stats (Sockets, Drops, etc.)
+	Raw6      UdpStat                      `json:"raw6,omitempty"`      // RAW6 socket stats (Sockets, Drops, etc.)
+	Snmp      map[string]map[string]uint64 `json:"snmp,omitempty"`      // Protocol counters per protocol (RetransSegs, InErrors, etc.)
+	Ports     PortsStat                    `json:"ports,omitempty"`     // Listening ports compared with published ports
+	Peers     map[string]PeerStat          `json:"peers,omitempty"`     // TCP/TCP6 connections aggregated by remote endpoint
+	Sockstat  map[string]map[string]uint64 `json:"sockstat,omitempty"`  // Summary of sockets usage per protocol (inuse, orphan, mem, etc.)
+	Conntrack ConntrackStat                `json:"conntrack,omitempty"` // Usage of connection tracking table (count, max, drop, etc.)
 }
 
 // TcpStat holds statistics about count of connections in different states
@@ -296,6 +535,88 @@ type TcpStat struct {
 	Listen uint64 `json:"listen,omitempty"`
 	//Count of TCP connections in state "Closing"
 	Closing uint64 `json:"closing,omitempty"`
+	//Sum of bytes in transmit queues (listening sockets excluded)
+	TxQueue uint64 `json:"tx_queue,omitempty"`
+	//Sum of bytes in receive queues (listening sockets excluded)
+	RxQueue uint64 `json:"rx_queue,omitempty"`
+	//Count of TCP connections with non-empty transmit or receive queue
+	NonEmptyQueues uint64 `json:"non_empty_queues,omitempty"`
+	//Count of connections waiting in accept queue of listening sockets per local port
+	ListenBacklog map[string]uint64 `json:"listen_backlog,omitempty"`
+}
+
+// UdpStat holds statistics about UDP sockets (RAW sockets are described by the same statistics)
+type UdpStat struct {
+	//Count of sockets
+	Sockets uint64 `json:"sockets,omitempty"`
+	//Sum of bytes in transmit queues
+	TxQueue uint64 `json:"tx_queue,omitempty"`
+	//Sum of bytes in receive queues
+	RxQueue uint64 `json:"rx_queue,omitempty"`
+	//Count of dropped datagrams
+	Drops uint64 `json:"drops,omitempty"`
+}
+
+// UnixStat holds statistics about count of UNIX domain sockets of different types and in different states
+type UnixStat struct {
+	//Count of sockets
+	Sockets uint64 `json:"sockets,omitempty"`
+	//Count of sockets of type "Stream"
+	Stream uint64 `json:"stream,omitempty"`
+	//Count of sockets of type "Dgram"
+	Dgram uint64 `json:"dgram,omitempty"`
+	//Count of sockets of type "SeqPacket"
+	SeqPacket uint64 `json:"seqpacket,omitempty"`
+	//Count of sockets in state "Unconnected"
+	Unconnected uint64 `json:"unconnected,omitempty"`
+	//Count of sockets in state "Connecting"
+	Connecting uint64 `json:"connecting,omitempty"`
+	//Count of sockets in state "Connected"
+	Connected uint64 `json:"connected,omitempty"`
+	//Count of sockets in state "Disconnecting"
+	Disconnecting uint64 `json:"disconnecting,omitempty"`
+	//Count of listening sockets
+	Listening uint64 `json:"listening,omitempty"`
+}
+
+// PortsStat holds inventory of ports on which a container listens compared with ports published by the container
+type PortsStat struct {
+	//Count of listening ports
+	Listening uint64 `json:"listening,omitempty"`
+	//Count of published ports
+	Published uint64 `json:"published,omitempty"`
+	//Count of listening ports which are not published
+	Unpublished uint64 `json:"unpublished,omitempty"`
+	//Count of published ports on which nothing listens
+	NotListening uint64 `json:"not_listening,omitempty"`
+	//Listening and published ports keyed by port and protocol (e.g. 80_tcp)
+	PerPort map[string]PortStat `json:"per_port,omitempty"`
+}
+
+// PortStat says whether a port is listened on and whether it is published (1 for true, 0 for false)
+type PortStat struct {
+	Listening uint64 `json:"listening"`
+	Published uint64 `json:"published"`
+}
+
+// PeerStat holds statistics about connections to a remote endpoint
+type PeerStat struct {
+	//Count of TCP/TCP6 connections in state "Established"
+	Established uint64 `json:"established"`
+}
+
+// ConntrackStat holds statistics about usage of connection tracking table
+type ConntrackStat struct {
+	//Count of entries in conntrack table
+	Count uint64 `json:"count"`
+	//Maximum number of entries in conntrack table
+	Max uint64 `json:"max"`
+	//Count of entries which could not be inserted into conntrack table
+	InsertFailed uint64 `json:"insert_failed"`
+	//Count of packets dropped because conntrack entry could not be created
+	Drop uint64 `json:"drop"`
+	//Count of entries dropped to make room for new ones when conntrack table was full
+	EarlyDrop uint64 `json:"early_drop"`
 }
 
 // NewStatistics returns pointer to initialized Statistics
@@ -304,10 +625,15 @@ func NewStatistics() *Statistics {
 		Network: []NetworkInterface{},
 		Cgroups: newCgroupsStats(),
 		Connection: TcpInterface{
-			Tcp:  TcpStat{},
-			Tcp6: TcpStat{},
+			Tcp:      TcpStat{ListenBacklog: map[string]uint64{}},
+			Tcp6:     TcpStat{ListenBacklog: map[string]uint64{}},
+			Snmp:     newSnmpStats(),
+			Ports:    PortsStat{PerPort: map[string]PortStat{}},
+			Peers:    map[string]PeerStat{},
+			Sockstat: newSockstatStats(),
 		},
 		Filesystem: map[string]FilesystemInterface{},
+		Qdisc:      map[string]map[string]QdiscStat{},
 	}
 }
 
@@ -322,6 +648,45 @@ func newCgroupsStats() *Cgroups {
 	return &cgroups
 }
 
+func newSnmpStats() map[string]map[string]uint64 {
+	snmp := make(map[string]map[string]uint64)
+	for _, proto := range listOfSnmpProtocols {
+		snmp[proto] = make(map[string]uint64)
+	}
+	return snmp
+}
+
+func newSockstatStats() map[string]map[string]uint64 {
+	sockstat := make(map[string]map[string]uint64)
+	for proto, counters := range listOfSockstatCounters {
+		sockstat[proto] = make(map[string]uint64)
+		for _, counter := range counters {
+			sockstat[proto][counter] = 0
+		}
+	}
+	return sockstat
+}
+
+// listOfSockstatCounters holds counters available in net/sockstat and net/sockstat6 per protocol
+var listOfSockstatCounters = map[string][]string{
+	"sockets":   {"used"},
+	"tcp":       {"inuse", "orphan", "tw", "alloc", "mem"},
+	"udp":       {"inuse", "mem"},
+	"udp_lite":  {"inuse"},
+	"raw":       {"inuse"},
+	"frag":      {"inuse", "memory"},
+	"tcp6":      {"inuse"},
+	"udp6":      {"inuse"},
+	"udp_lite6": {"inuse"},
+	"raw6":      {"inuse"},
+	"frag6":     {"inuse", "memory"},
+}
+
+// listOfSnmpProtocols holds protocols which counters are available in net/snmp, net/netstat and net/snmp6
+var listOfSnmpProtocols = []string{
+	"icmp", "icmp6", "icmp_msg", "ip", "ip6", "ip_ext", "tcp", "tcp_ext", "udp", "udp6", "udp_lite", "udp_lite6",
+}
+
 var listOfMemoryStats = []string{
 	"active_anon", "active_file", "inactive_anon", "inactive_file", "cache", "dirty", "swap",
 	"hierarchical_memory_limit", "hierarchical_memsw_limit", "mapped_file", "pgfault", "pgmajfault", "pgpgin",
@@ -0,0 +1,203 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package container
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testAuthority signs certificates of a stand-in docker daemon and its clients
+type testAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestAuthority() (*testAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "docker test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &testAuthority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}, nil
+}
+
+// sign returns PEM encoded certificate and its private key, certificate of server is valid for localhost
+func (ca *testAuthority) sign(serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte, _ error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "docker test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// newTLSDaemon starts a stand-in docker daemon which requires clients to present certificate signed by the given authority
+func newTLSDaemon(ca *testAuthority) (*httptest.Server, error) {
+	certPEM, keyPEM, err := ca.sign(2, x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	clients := x509.NewCertPool()
+	clients.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			w.Write([]byte("OK"))
+		case strings.HasSuffix(r.URL.Path, "/version"):
+			w.Write([]byte(`{"Version":"17.03.1-ce","ApiVersion":"1.27"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clients,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+
+	return server, nil
+}
+
+func TestDockerTLSClient(t *testing.T) {
+	Convey("Communicate with docker endpoint secured by TLS", t, func() {
+		dir, err := ioutil.TempDir("", "docker-tls")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		ca, err := newTestAuthority()
+		So(err, ShouldBeNil)
+		other, err := newTestAuthority()
+		So(err, ShouldBeNil)
+		certPEM, keyPEM, err := ca.sign(3, x509.ExtKeyUsageClientAuth)
+		So(err, ShouldBeNil)
+
+		files := map[string][]byte{"ca.pem": ca.pem, "other-ca.pem": other.pem, "cert.pem": certPEM, "key.pem": keyPEM, "invalid.pem": []byte("not a certificate")}
+		for name, content := range files {
+			So(ioutil.WriteFile(filepath.Join(dir, name), content, 0600), ShouldBeNil)
+		}
+		path := func(name string) string { return filepath.Join(dir, name) }
+
+		daemon, err := newTLSDaemon(ca)
+		So(err, ShouldBeNil)
+		defer daemon.Close()
+		endpoint := "tcp://" + daemon.Listener.Addr().String()
+
+		Convey("successful when certificate of daemon is verified and client certificate is given", func() {
+			dc, err := NewDockerTLSClient(endpoint, TLSOptions{CA: path("ca.pem"), Cert: path("cert.pem"), Key: path("key.pem"), Verify: true})
+			So(err, ShouldBeNil)
+			So(dc, ShouldNotBeNil)
+		})
+
+		Convey("successful when verification of daemon certificate is disabled", func() {
+			dc, err := NewDockerTLSClient(endpoint, TLSOptions{CA: path("other-ca.pem"), Cert: path("cert.pem"), Key: path("key.pem"), Verify: false})
+			So(err, ShouldBeNil)
+			So(dc, ShouldNotBeNil)
+		})
+
+		Convey("return an error when certificate of daemon is signed by other authority", func() {
+			_, err := NewDockerTLSClient(endpoint, TLSOptions{CA: path("other-ca.pem"), Cert: path("cert.pem"), Key: path("key.pem"), Verify: true})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when certificate of daemon is verified with system roots", func() {
+			_, err := NewDockerTLSClient(endpoint, TLSOptions{Cert: path("cert.pem"), Key: path("key.pem"), Verify: true})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("return an error when client certificate is not given", func() {
+			_, err := NewDockerTLSClient(endpoint, TLSOptions{CA: path("ca.pem"), Verify: true})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("validation of certificates", func() {
+			So(TLSOptions{}.Enabled(), ShouldBeFalse)
+			So(TLSOptions{Verify: true}.Enabled(), ShouldBeFalse)
+			So(TLSOptions{CA: path("ca.pem")}.Enabled(), ShouldBeTrue)
+
+			So(TLSOptions{CA: path("ca.pem"), Cert: path("cert.pem"), Key: path("key.pem")}.Validate(), ShouldBeNil)
+			So(TLSOptions{CA: path("ca.pem")}.Validate(), ShouldBeNil)
+			// key of client certificate is missing
+			So(TLSOptions{Cert: path("cert.pem")}.Validate(), ShouldNotBeNil)
+			// client certificate does not match its key
+			So(TLSOptions{Cert: path("cert.pem"), Key: path("ca.pem")}.Validate(), ShouldNotBeNil)
+			So(TLSOptions{CA: path("missing.pem")}.Validate(), ShouldNotBeNil)
+			So(TLSOptions{CA: path("invalid.pem")}.Validate(), ShouldNotBeNil)
+		})
+	})
+}
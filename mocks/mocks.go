@@ -20,6 +20,8 @@ limitations under the License.
 package mocks
 
 import (
+	"time"
+
 	"github.com/fsouza/go-dockerclient"
 	"github.com/stretchr/testify/mock"
 
@@ -51,7 +53,7 @@ func (cm *ClientMock) NewDockerClient() (*container.DockerClient, error) {
 	return r0, args.Error(1)
 }
 
-func (cm *ClientMock) ListContainersAsMap() (map[string]*container.ContainerData, error) {
+func (cm *ClientMock) ListContainersAsMap(opts container.ListOptions) (map[string]*container.ContainerData, error) {
 	args := cm.Called()
 
 	var r0 map[string]*container.ContainerData
@@ -73,11 +75,100 @@ func (cm *ClientMock) InspectContainer(string) (*docker.Container, error) {
 	return r0, args.Error(1)
 }
 
+func (cm *ClientMock) ReinspectContainer(id string) (*docker.Container, error) {
+	args := cm.Called(id)
+
+	var r0 *docker.Container
+
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*docker.Container)
+	}
+
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) GetHostConfigExtras(id string) (*container.HostConfigExtras, error) {
+	args := cm.Called(id)
+
+	var r0 *container.HostConfigExtras
+
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*container.HostConfigExtras)
+	}
+
+	return r0, args.Error(1)
+}
+
 func (cm *ClientMock) GetDockerParams(params ...string) (map[string]string, error) {
 	ret := cm.Called(params)
 	return ret.Get(0).(map[string]string), ret.Error(1)
 }
 
+func (cm *ClientMock) GetDockerVersion() []int {
+	ret := cm.Called()
+	return ret.Get(0).([]int)
+}
+
+func (cm *ClientMock) ListNetworks() ([]docker.Network, error) {
+	args := cm.Called()
+
+	var r0 []docker.Network
+	if args.Get(0) != nil {
+		r0 = args.Get(0).([]docker.Network)
+	}
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) GetEventsStats() (map[string]container.EventsStats, error) {
+	args := cm.Called()
+
+	var r0 map[string]container.EventsStats
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(map[string]container.EventsStats)
+	}
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) AddEventHandler(handler func(id, action string)) error {
+	args := cm.Called()
+	return args.Error(0)
+}
+
+func (cm *ClientMock) GetDaemonInfo() (*container.DaemonInfo, error) {
+	args := cm.Called()
+
+	var r0 *container.DaemonInfo
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*container.DaemonInfo)
+	}
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) GetDiskUsage(interval time.Duration) (*container.DiskUsage, error) {
+	args := cm.Called()
+
+	var r0 *container.DiskUsage
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(*container.DiskUsage)
+	}
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) GetImagesInventory() (map[string]container.ImageInventory, error) {
+	args := cm.Called()
+
+	var r0 map[string]container.ImageInventory
+	if args.Get(0) != nil {
+		r0 = args.Get(0).(map[string]container.ImageInventory)
+	}
+	return r0, args.Error(1)
+}
+
+func (cm *ClientMock) Close() error {
+	args := cm.Called()
+	return args.Error(0)
+}
+
 var MockGetters map[string]container.StatGetter = map[string]container.StatGetter{
 	"cpu_usage":  &MockCpuAcct{},
 	"cache":      &MockMemCache{},
@@ -86,6 +177,17 @@ var MockGetters map[string]container.StatGetter = map[string]container.StatGette
 	"network":    &MockNet{},
 	"tcp":        &MockTcp{},
 	"tcp6":       &MockTcp{},
+	"udp":        &MockUdp{},
+	"udp6":       &MockUdp{},
+	"unix":       &MockUnix{},
+	"raw":        &MockUdp{},
+	"raw6":       &MockUdp{},
+	"snmp":       &MockSnmp{},
+	"ports":      &MockPorts{},
+	"peers":      &MockPeers{},
+	"sockstat":   &MockSockstat{},
+	"conntrack":  &MockConntrack{},
+	"qdisc":      &MockQdisc{},
 }
 
 type MockCpuAcct struct{}
@@ -129,5 +231,76 @@ type MockTcp struct{}
 
 func (m *MockTcp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
 	stats.Connection.Tcp.Established = 1111
+	stats.Connection.Tcp.ListenBacklog = map[string]uint64{"80": 1111, "443": 2222}
+	return nil
+}
+
+type MockUdp struct{}
+
+func (m *MockUdp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Udp.Sockets = 1111
+	return nil
+}
+
+type MockUnix struct{}
+
+func (m *MockUnix) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Unix.Stream = 1111
+	return nil
+}
+
+type MockSnmp struct{}
+
+func (m *MockSnmp) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Snmp["tcp"] = map[string]uint64{"RetransSegs": 1111, "OutRsts": 2222}
+	return nil
+}
+
+type MockPorts struct{}
+
+func (m *MockPorts) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Ports.PerPort = map[string]container.PortStat{
+		"80_tcp":  {Listening: 1, Published: 1},
+		"443_tcp": {Listening: 0, Published: 1},
+	}
+	return nil
+}
+
+type MockPeers struct{}
+
+func (m *MockPeers) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Peers = map[string]container.PeerStat{
+		"10_0_0_1": {Established: 1111},
+		"other":    {Established: 2222},
+	}
+	return nil
+}
+
+type MockSockstat struct{}
+
+func (m *MockSockstat) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Sockstat["tcp"]["orphan"] = 1111
+	return nil
+}
+
+type MockConntrack struct{}
+
+func (m *MockConntrack) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Connection.Conntrack = container.ConntrackStat{Count: 1111, Max: 2222}
+	return nil
+}
+
+type MockQdisc struct{}
+
+func (m *MockQdisc) GetStats(stats *container.Statistics, opts container.GetStatOpt) error {
+	stats.Qdisc = map[string]map[string]container.QdiscStat{
+		"eth0": {
+			"1:0":  {Bytes: 1111, Drops: 11},
+			"1:10": {Bytes: 2222, Drops: 22},
+		},
+		"veth1a2b3c": {
+			"0:0": {Bytes: 3333, Drops: 33},
+		},
+	}
 	return nil
 }